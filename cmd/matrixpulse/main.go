@@ -16,6 +16,9 @@ import (
 	"matrixpulse/internal/engine"
 	"matrixpulse/internal/feed"
 	"matrixpulse/internal/persist"
+	"matrixpulse/internal/record"
+	"matrixpulse/internal/types"
+	"matrixpulse/internal/validate"
 )
 
 var (
@@ -56,11 +59,36 @@ func run() error {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
 	// Initialize core components
-	eng := engine.New(cfg.Symbols, cfg.WindowSize, cfg.Alerts)
-	dataFeed := feed.NewSimulated(cfg.Symbols)
+	eng := engine.New(cfg.Symbols, cfg.WindowSize, cfg.Alerts, cfg.Engine)
+	dataFeed, err := feed.New(cfg.Symbols, cfg.Feed)
+	if err != nil {
+		return fmt.Errorf("failed to create feed: %w", err)
+	}
+	log.Printf("Using %s feed", dataFeed.Name())
 
 	// Start data ingestion
 	tickCh := dataFeed.Start(ctx)
+	defer dataFeed.Stop()
+
+	// Session recording
+	var rec *record.Recorder
+	if cfg.Recording.Enabled {
+		rec, err = record.New(cfg.Recording)
+		if err != nil {
+			return fmt.Errorf("failed to start recorder: %w", err)
+		}
+		log.Printf("Recording ticks to %s", cfg.Recording.Dir)
+	}
+
+	// Tick validation
+	var val *validate.Validator
+	if cfg.Validation.Enabled {
+		val, err = validate.New(cfg.Validation)
+		if err != nil {
+			return fmt.Errorf("failed to start validator: %w", err)
+		}
+		defer val.Close()
+	}
 
 	// WaitGroup for goroutine tracking
 	var wg sync.WaitGroup
@@ -69,9 +97,18 @@ func run() error {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		ingestLoop(ctx, eng, tickCh)
+		ingestLoop(ctx, eng, tickCh, rec, val)
 	}()
 
+	// Feed alerts (e.g. failover events)
+	if al, ok := dataFeed.(feed.Alerter); ok {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			alertLoop(ctx, eng, al.Alerts())
+		}()
+	}
+
 	// Compute loop
 	wg.Add(1)
 	go func() {
@@ -137,10 +174,18 @@ func run() error {
 	return nil
 }
 
-func ingestLoop(ctx context.Context, eng *engine.Engine, tickCh <-chan feed.Tick) {
+func ingestLoop(ctx context.Context, eng *engine.Engine, tickCh <-chan feed.Tick, rec *record.Recorder, val *validate.Validator) {
 	log.Println("Ingestion loop started")
 	defer log.Println("Ingestion loop stopped")
 
+	if rec != nil {
+		defer func() {
+			if err := rec.Close(); err != nil {
+				log.Printf("Error closing recording: %v", err)
+			}
+		}()
+	}
+
 	tickCount := 0
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
@@ -154,12 +199,49 @@ func ingestLoop(ctx context.Context, eng *engine.Engine, tickCh <-chan feed.Tick
 				log.Println("Tick channel closed")
 				return
 			}
+			if rec != nil {
+				if err := rec.Write(tick, time.Now()); err != nil {
+					log.Printf("Recording error: %v", err)
+				}
+			}
+			if val != nil && !val.Check(tick) {
+				continue
+			}
 			eng.Ingest(tick)
 			tickCount++
 		case <-ticker.C:
+			if rec != nil {
+				if err := rec.Flush(); err != nil {
+					log.Printf("Recording error: %v", err)
+				}
+			}
 			log.Printf("Ingested %d ticks (%.1f ticks/sec)",
 				tickCount, float64(tickCount)/5.0)
 			tickCount = 0
+
+			if val != nil {
+				st := val.Stats()
+				if len(st.Rejected) > 0 {
+					log.Printf("Validation: %d accepted, rejected %v", st.Accepted, st.Rejected)
+				}
+				if st.QuarantineErrors > 0 {
+					log.Printf("Validation: %d rejected ticks could not be written to the quarantine log", st.QuarantineErrors)
+				}
+			}
+		}
+	}
+}
+
+func alertLoop(ctx context.Context, eng *engine.Engine, alertCh <-chan types.Alert) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a, ok := <-alertCh:
+			if !ok {
+				return
+			}
+			eng.Raise(a)
 		}
 	}
 }
@@ -25,8 +25,11 @@ func main() {
 	cfg := config.Load()
 	ctx, cancel := context.WithCancel(context.Background())
 
-	eng := engine.New(cfg.Symbols, cfg.WindowSize, cfg.Alerts)
-	dataFeed := feed.NewSimulated(cfg.Symbols)
+	eng := engine.New(cfg.Symbols, cfg.WindowSize, cfg.Alerts, cfg.Engine)
+	dataFeed, err := feed.New(cfg.Symbols, cfg.Feed)
+	if err != nil {
+		log.Fatalf("failed to create feed: %v", err)
+	}
 	tickCh := dataFeed.Start(ctx)
 
 	go func() {
@@ -0,0 +1,74 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TradingDay is one 6.5-hour equity session and TradingYear is 252 of
+// them: the year that annualized drift and volatility refer to.
+const (
+	TradingDay  = 6*time.Hour + 30*time.Minute
+	TradingYear = 252 * TradingDay
+)
+
+// Clock abstracts the passage of time for components that need to run
+// either against the wall clock or on virtual time.
+type Clock interface {
+	Now() time.Time
+	// SleepUntil blocks until the clock reaches t or ctx is done.
+	SleepUntil(ctx context.Context, t time.Time) error
+}
+
+// Real is the wall clock.
+type Real struct{}
+
+func (Real) Now() time.Time { return time.Now() }
+
+func (Real) SleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Virtual is a clock that jumps straight to whatever time it is asked to
+// sleep until, so code driven by it runs as fast as it can be consumed.
+type Virtual struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewVirtual(start time.Time) *Virtual {
+	return &Virtual{now: start}
+}
+
+func (v *Virtual) Now() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.now
+}
+
+func (v *Virtual) SleepUntil(ctx context.Context, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	if t.After(v.now) {
+		v.now = t
+	}
+	v.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package cluster builds hierarchical clusterings of symbols from a
+// correlation matrix.
+package cluster
+
+import (
+	"math"
+
+	"matrixpulse/internal/types"
+)
+
+// Distances turns correlations into distances. "angular" is
+// √(2(1-ρ)), a proper metric; anything else gives 1-ρ. Both are 0 for
+// perfectly correlated pairs and grow as correlation falls.
+func Distances(cor [][]float64, metric string) [][]float64 {
+	n := len(cor)
+	d := make([][]float64, n)
+	for i := range d {
+		d[i] = make([]float64, n)
+		for j := range d[i] {
+			if i == j {
+				continue
+			}
+			v := math.Max(1-cor[i][j], 0)
+			if metric == "angular" {
+				v = math.Sqrt(2 * v)
+			}
+			d[i][j] = v
+		}
+	}
+	return d
+}
+
+// Build clusters n items agglomeratively with the given linkage
+// ("single", "average" or "ward"), updating distances with the
+// Lance–Williams recurrences. Merge k creates node n+k; nodes below n
+// are the items themselves. A NaN or infinite distance, as a symbol
+// with a flat price gives, counts as the largest finite one, so such
+// items join last.
+func Build(dist [][]float64, linkage string) []types.Merge {
+	n := len(dist)
+	if n < 2 {
+		return nil
+	}
+
+	far := 0.0
+	for i := range dist {
+		for _, v := range dist[i] {
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				far = math.Max(far, v)
+			}
+		}
+	}
+
+	// d holds distances between active clusters, indexed by slot; slot i
+	// starts as item i and is reused by the cluster it merges into.
+	d := make([][]float64, n)
+	for i := range d {
+		d[i] = append([]float64(nil), dist[i]...)
+		for j, v := range d[i] {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				d[i][j] = far
+			}
+		}
+	}
+	node := make([]int, n)
+	size := make([]int, n)
+	active := make([]bool, n)
+	for i := range node {
+		node[i] = i
+		size[i] = 1
+		active[i] = true
+	}
+
+	merges := make([]types.Merge, 0, n-1)
+	for step := 0; step < n-1; step++ {
+		a, b := -1, -1
+		best := math.Inf(1)
+		for i := 0; i < n; i++ {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if active[j] && d[i][j] < best {
+					best, a, b = d[i][j], i, j
+				}
+			}
+		}
+
+		na, nb := float64(size[a]), float64(size[b])
+		for k := 0; k < n; k++ {
+			if !active[k] || k == a || k == b {
+				continue
+			}
+			var v float64
+			switch linkage {
+			case "single":
+				v = math.Min(d[a][k], d[b][k])
+			case "ward":
+				nk := float64(size[k])
+				t := na + nb + nk
+				v = math.Sqrt(math.Max(((na+nk)*d[a][k]*d[a][k]+
+					(nb+nk)*d[b][k]*d[b][k]-
+					nk*best*best)/t, 0))
+			default: // average
+				v = (na*d[a][k] + nb*d[b][k]) / (na + nb)
+			}
+			d[a][k] = v
+			d[k][a] = v
+		}
+
+		left, right := node[a], node[b]
+		if left > right {
+			left, right = right, left
+		}
+		merges = append(merges, types.Merge{
+			Left:   left,
+			Right:  right,
+			Height: best,
+			Size:   size[a] + size[b],
+		})
+
+		node[a] = n + step
+		size[a] += size[b]
+		active[b] = false
+	}
+	return merges
+}
+
+// Cut returns a flat cluster label for each of the n items, joining
+// every merge at or below height. Labels are numbered from 0 in order of
+// each cluster's first item.
+func Cut(merges []types.Merge, n int, height float64) []int {
+	k := 0
+	for _, m := range merges {
+		if m.Height <= height {
+			k++
+		}
+	}
+	return cutAfter(merges, n, k)
+}
+
+// CutCount returns labels for exactly k clusters (fewer when there are
+// fewer items).
+func CutCount(merges []types.Merge, n, k int) []int {
+	if k < 1 {
+		k = 1
+	}
+	if k > n {
+		k = n
+	}
+	return cutAfter(merges, n, n-k)
+}
+
+// cutAfter applies the first steps merges with a union-find.
+func cutAfter(merges []types.Merge, n, steps int) []int {
+	parent := make([]int, 2*n)
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(x int) int {
+		for parent[x] != x {
+			parent[x] = parent[parent[x]]
+			x = parent[x]
+		}
+		return x
+	}
+
+	for k := 0; k < steps && k < len(merges); k++ {
+		id := n + k
+		parent[find(merges[k].Left)] = id
+		parent[find(merges[k].Right)] = id
+	}
+
+	labels := make([]int, n)
+	seen := make(map[int]int)
+	for i := 0; i < n; i++ {
+		root := find(i)
+		l, ok := seen[root]
+		if !ok {
+			l = len(seen)
+			seen[root] = l
+		}
+		labels[i] = l
+	}
+	return labels
+}
+
+// Order returns the items in dendrogram leaf order, the quasi-diagonal
+// ordering: reordering a correlation matrix this way puts correlated
+// symbols next to each other, so clusters show as blocks on the
+// diagonal.
+func Order(merges []types.Merge, n int) []int {
+	if n == 0 {
+		return nil
+	}
+	if len(merges) == 0 {
+		return []int{0}
+	}
+
+	order := make([]int, 0, n)
+	stack := []int{n + len(merges) - 1}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id < n {
+			order = append(order, id)
+			continue
+		}
+		m := merges[id-n]
+		stack = append(stack, m.Right, m.Left)
+	}
+	return order
+}
@@ -0,0 +1,199 @@
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"matrixpulse/internal/types"
+)
+
+// naiveBuild clusters points by recomputing every inter-cluster
+// distance from its definition at each step, O(n⁴), as a check on the
+// Lance–Williams updates in Build.
+func naiveBuild(pts [][]float64, linkage string) []types.Merge {
+	n := len(pts)
+	type group struct {
+		id      int
+		members []int
+	}
+	groups := make([]group, n)
+	for i := range groups {
+		groups[i] = group{id: i, members: []int{i}}
+	}
+
+	link := func(a, b []int) float64 {
+		switch linkage {
+		case "single":
+			v := math.Inf(1)
+			for _, i := range a {
+				for _, j := range b {
+					v = math.Min(v, euclid(pts[i], pts[j]))
+				}
+			}
+			return v
+		case "ward":
+			// √(2·na·nb/(na+nb)) times the distance between centroids,
+			// which is what the recurrence yields from Euclidean inputs.
+			na, nb := float64(len(a)), float64(len(b))
+			return math.Sqrt(2*na*nb/(na+nb)) * euclid(centroid(pts, a), centroid(pts, b))
+		default:
+			sum := 0.0
+			for _, i := range a {
+				for _, j := range b {
+					sum += euclid(pts[i], pts[j])
+				}
+			}
+			return sum / float64(len(a)*len(b))
+		}
+	}
+
+	var merges []types.Merge
+	for step := 0; len(groups) > 1; step++ {
+		a, b := 0, 1
+		best := math.Inf(1)
+		for i := range groups {
+			for j := i + 1; j < len(groups); j++ {
+				if v := link(groups[i].members, groups[j].members); v < best {
+					best, a, b = v, i, j
+				}
+			}
+		}
+
+		left, right := groups[a].id, groups[b].id
+		if left > right {
+			left, right = right, left
+		}
+		members := append(append([]int(nil), groups[a].members...), groups[b].members...)
+		merges = append(merges, types.Merge{Left: left, Right: right, Height: best, Size: len(members)})
+
+		groups[a] = group{id: n + step, members: members}
+		groups = append(groups[:b], groups[b+1:]...)
+	}
+	return merges
+}
+
+func euclid(x, y []float64) float64 {
+	sum := 0.0
+	for i := range x {
+		sum += (x[i] - y[i]) * (x[i] - y[i])
+	}
+	return math.Sqrt(sum)
+}
+
+func centroid(pts [][]float64, members []int) []float64 {
+	c := make([]float64, len(pts[0]))
+	for _, i := range members {
+		for k, v := range pts[i] {
+			c[k] += v / float64(len(members))
+		}
+	}
+	return c
+}
+
+func TestBuildMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	for _, linkage := range []string{"single", "average", "ward"} {
+		for trial := 0; trial < 20; trial++ {
+			n := 2 + rng.Intn(14)
+			pts := make([][]float64, n)
+			for i := range pts {
+				pts[i] = []float64{rng.NormFloat64(), rng.NormFloat64(), rng.NormFloat64()}
+			}
+			dist := make([][]float64, n)
+			for i := range dist {
+				dist[i] = make([]float64, n)
+				for j := range dist[i] {
+					dist[i][j] = euclid(pts[i], pts[j])
+				}
+			}
+
+			got, want := Build(dist, linkage), naiveBuild(pts, linkage)
+			if len(got) != n-1 {
+				t.Fatalf("%s trial %d: %d merges for %d items", linkage, trial, len(got), n)
+			}
+			for k := range want {
+				g, w := got[k], want[k]
+				if g.Left != w.Left || g.Right != w.Right || g.Size != w.Size ||
+					math.Abs(g.Height-w.Height) > 1e-9 {
+					t.Fatalf("%s trial %d merge %d = %+v, naive %+v", linkage, trial, k, g, w)
+				}
+			}
+		}
+	}
+}
+
+func TestCutAndOrder(t *testing.T) {
+	// Items on a line at 5, 0, 7 and 1: {1,3} join at 1, {0,2} at 2 and
+	// the two pairs at 4 under single linkage.
+	x := []float64{5, 0, 7, 1}
+	dist := make([][]float64, len(x))
+	for i := range dist {
+		dist[i] = make([]float64, len(x))
+		for j := range dist[i] {
+			dist[i][j] = math.Abs(x[i] - x[j])
+		}
+	}
+	merges := Build(dist, "single")
+	want := []types.Merge{
+		{Left: 1, Right: 3, Height: 1, Size: 2},
+		{Left: 0, Right: 2, Height: 2, Size: 2},
+		{Left: 4, Right: 5, Height: 4, Size: 4},
+	}
+	if !reflect.DeepEqual(merges, want) {
+		t.Fatalf("Build = %+v, want %+v", merges, want)
+	}
+
+	for _, tc := range []struct {
+		name string
+		got  []int
+		want []int
+	}{
+		{"Cut(0.5)", Cut(merges, 4, 0.5), []int{0, 1, 2, 3}},
+		{"Cut(1.5)", Cut(merges, 4, 1.5), []int{0, 1, 2, 1}},
+		{"Cut(2)", Cut(merges, 4, 2), []int{0, 1, 0, 1}},
+		{"Cut(10)", Cut(merges, 4, 10), []int{0, 0, 0, 0}},
+		{"CutCount(0)", CutCount(merges, 4, 0), []int{0, 0, 0, 0}},
+		{"CutCount(2)", CutCount(merges, 4, 2), []int{0, 1, 0, 1}},
+		{"CutCount(9)", CutCount(merges, 4, 9), []int{0, 1, 2, 3}},
+		{"Order", Order(merges, 4), []int{1, 3, 0, 2}},
+	} {
+		if !reflect.DeepEqual(tc.got, tc.want) {
+			t.Errorf("%s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+// TestBuildNonFinite is a regression test: a NaN distance used to leave
+// no pair selected and panic on index -1.
+func TestBuildNonFinite(t *testing.T) {
+	nan := math.NaN()
+	cor := [][]float64{
+		{1, 0.9, nan, 0.1},
+		{0.9, 1, nan, 0.2},
+		{nan, nan, 1, nan},
+		{0.1, 0.2, nan, 1},
+	}
+	for _, linkage := range []string{"single", "average", "ward"} {
+		merges := Build(Distances(cor, "angular"), linkage)
+		if len(merges) != 3 {
+			t.Fatalf("%s: %d merges, want 3", linkage, len(merges))
+		}
+		for _, m := range merges {
+			if math.IsNaN(m.Height) || math.IsInf(m.Height, 0) {
+				t.Fatalf("%s: merge %+v has a non-finite height", linkage, m)
+			}
+		}
+		// Item 2 is furthest from everything, so it joins last; Ward
+		// weighs cluster sizes too and may pair it first.
+		if last := merges[2]; linkage != "ward" && last.Left != 2 && last.Right != 2 {
+			t.Errorf("%s: last merge %+v, want item 2 in it", linkage, last)
+		}
+	}
+
+	all := [][]float64{{0, nan}, {nan, 0}}
+	if merges := Build(all, "average"); len(merges) != 1 || merges[0].Height != 0 {
+		t.Errorf("Build of all-NaN distances = %+v", merges)
+	}
+}
@@ -11,7 +11,11 @@ type Config struct {
 	Symbols     []string    `yaml:"symbols"`
 	WindowSize  int         `yaml:"window_size"`
 	UpdateHz    int         `yaml:"update_hz"`
+	Feed        Feed        `yaml:"feed"`
+	Validation  Validation  `yaml:"validation"`
+	Engine      Engine      `yaml:"engine"`
 	Alerts      Alerts      `yaml:"alerts"`
+	Recording   Recording   `yaml:"recording"`
 	Persistence Persistence `yaml:"persistence"`
 	Dashboard   Dashboard   `yaml:"dashboard"`
 }
@@ -19,7 +23,184 @@ type Config struct {
 type Alerts struct {
 	Correlation float64 `yaml:"correlation_threshold"`
 	Eigenvalue  float64 `yaml:"eigenvalue_threshold"`
-	Volatility  float64 `yaml:"volatility_threshold"`
+	// Volatility is compared with each symbol's annualized close-to-close
+	// volatility, so it means the same whatever the tick rate.
+	Volatility float64 `yaml:"volatility_threshold"`
+	// Systemic-risk indicators; 0 turns a check off. AbsorptionShift is
+	// in standard deviations and Entropy is a floor on the normalized
+	// spectral entropy.
+	AbsorptionRatio float64 `yaml:"absorption_ratio_threshold"`
+	AbsorptionShift float64 `yaml:"absorption_shift_threshold"`
+	Entropy         float64 `yaml:"entropy_threshold"`
+	// MinClusters alerts when the first clustering linkage yields fewer
+	// flat clusters than this; 0 turns it off.
+	MinClusters int `yaml:"min_clusters"`
+	// TreeLength alerts when the spanning tree's mean length falls this
+	// many standard deviations below its history; 0 turns it off.
+	TreeLength float64 `yaml:"tree_length_threshold"`
+	// LeadLag is the lagged correlation a stable lead needs before it is
+	// reported; 0 turns lead–lag alerts off.
+	LeadLag float64 `yaml:"lead_lag_threshold"`
+}
+
+// Validation screens ticks before ingestion. Non-positive and non-finite
+// prices are always rejected when enabled; the other checks are off at
+// zero. MaxJumpPct is measured against the median of the last
+// MedianWindow prices; it is off by default because it would also drop
+// genuine moves such as a scripted scenario's jumps. MaxAgeMs and
+// MaxFutureMs compare tick timestamps with the wall clock, so leave them
+// off for historical replay.
+type Validation struct {
+	Enabled          bool    `yaml:"enabled"`
+	MaxJumpPct       float64 `yaml:"max_jump_pct"`
+	MedianWindow     int     `yaml:"median_window"`
+	MaxAgeMs         int     `yaml:"max_age_ms"`
+	MaxFutureMs      int     `yaml:"max_future_ms"`
+	RejectDuplicates bool    `yaml:"reject_duplicates"`
+	QuarantinePath   string  `yaml:"quarantine_path"`
+}
+
+// Engine configures how prices become the matrices the engine publishes.
+type Engine struct {
+	Sampling Sampling `yaml:"sampling"`
+	// Estimator is "sample" for the sample covariance of aligned returns,
+	// "ewma" for the exponentially weighted one, or "hayashi_yoshida" for
+	// the asynchronous estimator over raw tick times.
+	Estimator      string         `yaml:"estimator"`
+	EWMA           EWMA           `yaml:"ewma"`
+	HayashiYoshida HayashiYoshida `yaml:"hayashi_yoshida"`
+	// Incremental keeps running sums for the sample estimator instead of
+	// recomputing it from the full windows on every update. It needs grid
+	// sampling, whose rows are the same in the windows and the sums, so
+	// shrinkage, RMT and rank measures describe the same sample.
+	Incremental bool `yaml:"incremental"`
+	// Correlation picks the measure published as the correlation matrix
+	// and used for eigen analysis: pearson, spearman or kendall. Also
+	// lists further measures to compute side by side.
+	Correlation string   `yaml:"correlation"`
+	Also        []string `yaml:"also"`
+	// Shrinkage regularises the sample covariance: none, ledoit_wolf
+	// (towards a scaled identity), oas (same target, Oracle Approximating
+	// intensity) or constant_correlation. Rank correlation matrices are
+	// blended towards the same target at the same intensity.
+	Shrinkage  string     `yaml:"shrinkage"`
+	RMT        RMT        `yaml:"rmt"`
+	PCA        PCA        `yaml:"pca"`
+	Absorption Absorption `yaml:"absorption"`
+	Precision  Precision  `yaml:"precision"`
+	Clustering Clustering `yaml:"clustering"`
+	Network    Network    `yaml:"network"`
+	LeadLag    LeadLag    `yaml:"lead_lag"`
+	// BarsPerYear annualizes volatility as √BarsPerYear times the
+	// per-sample figure. At 0 it is inferred from the timestamps,
+	// counting 6.5 trading hours per weekday; set it for markets that
+	// trade around the clock.
+	BarsPerYear float64 `yaml:"bars_per_year"`
+}
+
+// LeadLag correlates every pair at lags of 1 to MaxLag window samples in
+// both directions. A lead counts as stable after StableCycles updates
+// with the same peak lag above alerts.lead_lag_threshold; updates that
+// find no new prices in the windows do not count.
+type LeadLag struct {
+	Enabled      bool `yaml:"enabled"`
+	MaxLag       int  `yaml:"max_lag"`
+	StableCycles int  `yaml:"stable_cycles"`
+}
+
+// Network builds the minimum spanning tree and the graph of pairs with
+// |ρ| at or above Threshold, and compares the tree's mean length and each
+// symbol's centrality with their last History updates.
+type Network struct {
+	Enabled   bool    `yaml:"enabled"`
+	Threshold float64 `yaml:"threshold"`
+	History   int     `yaml:"history"`
+}
+
+// Clustering builds a dendrogram of the symbols for each linkage
+// (single, average or ward) from correlation distances: "correlation"
+// is 1-ρ and "angular" is √(2(1-ρ)). Flat clusters come from cutting at
+// CutHeight, or into exactly Clusters groups when that is set.
+type Clustering struct {
+	Enabled   bool     `yaml:"enabled"`
+	Linkages  []string `yaml:"linkages"`
+	Distance  string   `yaml:"distance"`
+	CutHeight float64  `yaml:"cut_height"`
+	Clusters  int      `yaml:"clusters"`
+}
+
+// Precision adds the inverse covariance and partial correlations to the
+// published matrix. Mode "inverse" inverts the covariance directly;
+// "glasso" fits a sparse inverse with the graphical lasso, where Lambda
+// is the L1 penalty on the correlation scale.
+type Precision struct {
+	Mode      string  `yaml:"mode"`
+	Lambda    float64 `yaml:"lambda"`
+	MaxIter   int     `yaml:"max_iter"`
+	Tolerance float64 `yaml:"tolerance"`
+}
+
+// Absorption sets the absorption ratio: Fraction of the eigenvalues
+// count as the top ones, and the standardized shift compares the mean
+// over the last ShortSec seconds with the last LongSec, however often
+// the engine computes.
+type Absorption struct {
+	Fraction float64 `yaml:"fraction"`
+	ShortSec float64 `yaml:"short_seconds"`
+	LongSec  float64 `yaml:"long_seconds"`
+}
+
+// PCA sets how many leading principal components get per-symbol
+// loadings in the published mode.
+type PCA struct {
+	Components int `yaml:"components"`
+}
+
+// RMT compares the eigenvalues against the Marchenko–Pastur band that
+// pure noise would produce for the current number of symbols and
+// returns. Clean publishes a correlation matrix with the noise
+// eigenvalues clipped. A CrisisMultiple above zero replaces
+// eigenvalue_threshold with that multiple of the band's upper edge.
+type RMT struct {
+	Enabled        bool    `yaml:"enabled"`
+	Clean          bool    `yaml:"clean"`
+	CrisisMultiple float64 `yaml:"crisis_multiple"`
+}
+
+// EWMA sets the decay of the exponentially weighted estimator, either
+// directly as Lambda or as a HalfLife in returns, which wins when set.
+// The estimate starts from the sample covariance of the first Warmup
+// rows of returns.
+type EWMA struct {
+	Lambda   float64 `yaml:"lambda"`
+	HalfLife float64 `yaml:"half_life"`
+	Warmup   int     `yaml:"warmup"`
+}
+
+// HayashiYoshida sets how much tick time the hayashi_yoshida estimator
+// covers. Its history is kept by time rather than by window_size ticks,
+// so a name ticking every few milliseconds and one ticking every few
+// seconds cover the same stretch.
+type HayashiYoshida struct {
+	WindowSec float64 `yaml:"window_seconds"`
+}
+
+// Sampling chooses what a window entry is. In "tick" mode every tick is
+// an entry, so windows line up only if symbols tick in lockstep. In
+// "grid" mode every IntervalMs of tick time adds one entry per symbol,
+// holding its last price as of that instant.
+type Sampling struct {
+	Mode       string `yaml:"mode"`
+	IntervalMs int    `yaml:"interval_ms"`
+}
+
+// Recording captures every ingested tick to session files in Dir,
+// rotating once a file reaches MaxMB or MaxMinutes.
+type Recording struct {
+	Enabled    bool   `yaml:"enabled"`
+	Dir        string `yaml:"dir"`
+	MaxMB      int    `yaml:"max_mb"`
+	MaxMinutes int    `yaml:"max_minutes"`
 }
 
 type Persistence struct {
@@ -63,10 +244,23 @@ func defaultConfig() *Config {
 		Symbols:    []string{"AAPL", "GOOGL", "MSFT", "AMZN", "TSLA", "META"},
 		WindowSize: 120,
 		UpdateHz:   40,
+		Feed:       defaultFeed(),
+		Validation: Validation{
+			Enabled:          true,
+			MedianWindow:     21,
+			RejectDuplicates: true,
+		},
+		Engine: DefaultEngine(),
 		Alerts: Alerts{
 			Correlation: 0.82,
 			Eigenvalue:  2.8,
-			Volatility:  0.04,
+			Volatility:  0.6,
+		},
+		Recording: Recording{
+			Enabled:    false,
+			Dir:        "sessions",
+			MaxMB:      64,
+			MaxMinutes: 60,
 		},
 		Persistence: Persistence{
 			Enabled:  true,
@@ -80,6 +274,54 @@ func defaultConfig() *Config {
 	}
 }
 
+// DefaultEngine returns the analysis defaults. engine.New falls back to
+// them for any setting left at its zero value.
+func DefaultEngine() Engine {
+	return Engine{
+		Sampling: Sampling{
+			Mode:       "tick",
+			IntervalMs: 1000,
+		},
+		Estimator: "sample",
+		EWMA: EWMA{
+			Lambda: 0.94,
+			Warmup: 30,
+		},
+		HayashiYoshida: HayashiYoshida{
+			WindowSec: 600,
+		},
+		Correlation: "pearson",
+		Shrinkage:   "none",
+		PCA: PCA{
+			Components: 3,
+		},
+		Absorption: Absorption{
+			Fraction: 0.2,
+			ShortSec: 1,
+			LongSec:  60,
+		},
+		Precision: Precision{
+			Mode:      "off",
+			Lambda:    0.1,
+			MaxIter:   100,
+			Tolerance: 1e-4,
+		},
+		Clustering: Clustering{
+			Linkages:  []string{"single", "average", "ward"},
+			Distance:  "angular",
+			CutHeight: 1.0,
+		},
+		Network: Network{
+			Threshold: 0.5,
+			History:   2400,
+		},
+		LeadLag: LeadLag{
+			MaxLag:       5,
+			StableCycles: 40,
+		},
+	}
+}
+
 // Validate checks configuration for errors
 func (c *Config) Validate() error {
 	if len(c.Symbols) == 0 {
@@ -102,6 +344,142 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("update_hz out of range (1-1000, got %d)", c.UpdateHz)
 	}
 
+	if err := c.Feed.validate(len(c.Symbols)); err != nil {
+		return err
+	}
+
+	if c.Validation.MaxJumpPct < 0 || c.Validation.MaxAgeMs < 0 || c.Validation.MaxFutureMs < 0 {
+		return fmt.Errorf("validation limits must not be negative")
+	}
+
+	if c.Validation.MaxJumpPct > 0 && c.Validation.MedianWindow < 3 {
+		return fmt.Errorf("validation median_window too small (min 3, got %d)", c.Validation.MedianWindow)
+	}
+
+	if m := c.Engine.Sampling.Mode; m != "tick" && m != "grid" {
+		return fmt.Errorf("sampling mode must be tick or grid (got %q)", m)
+	}
+
+	if c.Engine.Sampling.IntervalMs < 1 {
+		return fmt.Errorf("sampling interval_ms must be positive (got %d)", c.Engine.Sampling.IntervalMs)
+	}
+
+	for _, method := range append([]string{c.Engine.Correlation}, c.Engine.Also...) {
+		if method != "pearson" && method != "spearman" && method != "kendall" {
+			return fmt.Errorf("unknown correlation measure %q (want pearson, spearman or kendall)", method)
+		}
+	}
+
+	switch c.Engine.Shrinkage {
+	case "none":
+	case "ledoit_wolf", "oas", "constant_correlation":
+		if c.Engine.Estimator != "sample" {
+			return fmt.Errorf("shrinkage applies to the sample estimator only (got %q)", c.Engine.Estimator)
+		}
+	default:
+		return fmt.Errorf("unknown shrinkage %q (want none, ledoit_wolf, oas or constant_correlation)", c.Engine.Shrinkage)
+	}
+
+	if !c.Engine.RMT.Enabled && (c.Engine.RMT.Clean || c.Engine.RMT.CrisisMultiple > 0) {
+		return fmt.Errorf("rmt clean and crisis_multiple need rmt enabled")
+	}
+
+	if c.Engine.RMT.CrisisMultiple < 0 {
+		return fmt.Errorf("rmt crisis_multiple must not be negative (got %v)", c.Engine.RMT.CrisisMultiple)
+	}
+
+	if c.Engine.PCA.Components < 1 {
+		return fmt.Errorf("pca components must be at least 1 (got %d)", c.Engine.PCA.Components)
+	}
+
+	if f := c.Engine.Absorption.Fraction; f <= 0 || f > 1 {
+		return fmt.Errorf("absorption fraction must be in (0, 1] (got %v)", f)
+	}
+
+	if a := c.Engine.Absorption; a.ShortSec <= 0 || a.LongSec < 2*a.ShortSec {
+		return fmt.Errorf("absorption windows need short_seconds > 0 and long_seconds >= 2 x short_seconds (got %v, %v)", a.ShortSec, a.LongSec)
+	}
+
+	switch p := c.Engine.Precision; p.Mode {
+	case "off", "inverse":
+	case "glasso":
+		if p.Lambda <= 0 || p.MaxIter < 1 || p.Tolerance <= 0 {
+			return fmt.Errorf("glasso needs positive lambda, max_iter and tolerance (got %v, %d, %v)", p.Lambda, p.MaxIter, p.Tolerance)
+		}
+	default:
+		return fmt.Errorf("unknown precision mode %q (want off, inverse or glasso)", p.Mode)
+	}
+
+	if cl := c.Engine.Clustering; cl.Enabled {
+		if len(cl.Linkages) == 0 {
+			return fmt.Errorf("clustering needs at least one linkage")
+		}
+		for _, l := range cl.Linkages {
+			if l != "single" && l != "average" && l != "ward" {
+				return fmt.Errorf("unknown linkage %q (want single, average or ward)", l)
+			}
+		}
+		if cl.Distance != "correlation" && cl.Distance != "angular" {
+			return fmt.Errorf("unknown cluster distance %q (want correlation or angular)", cl.Distance)
+		}
+		if cl.CutHeight < 0 || cl.Clusters < 0 {
+			return fmt.Errorf("cluster cut_height and clusters must not be negative")
+		}
+	}
+
+	if nw := c.Engine.Network; nw.Enabled {
+		if nw.Threshold < 0 || nw.Threshold > 1 {
+			return fmt.Errorf("network threshold must be 0-1 (got %.2f)", nw.Threshold)
+		}
+		if nw.History < 2 {
+			return fmt.Errorf("network history must be at least 2 (got %d)", nw.History)
+		}
+	}
+
+	if ll := c.Engine.LeadLag; ll.Enabled && (ll.MaxLag < 1 || ll.StableCycles < 1) {
+		return fmt.Errorf("lead_lag needs max_lag and stable_cycles of at least 1 (got %d, %d)", ll.MaxLag, ll.StableCycles)
+	}
+
+	if c.Engine.Incremental && c.Engine.Estimator != "sample" {
+		return fmt.Errorf("incremental updates apply to the sample estimator only (got %q)", c.Engine.Estimator)
+	}
+
+	if c.Engine.Incremental && c.Engine.Sampling.Mode != "grid" {
+		return fmt.Errorf("incremental updates need grid sampling (got %q)", c.Engine.Sampling.Mode)
+	}
+
+	switch c.Engine.Estimator {
+	case "sample":
+	case "ewma":
+		if c.Engine.EWMA.HalfLife < 0 {
+			return fmt.Errorf("ewma half_life must not be negative (got %v)", c.Engine.EWMA.HalfLife)
+		}
+		if l := c.Engine.EWMA.Lambda; c.Engine.EWMA.HalfLife == 0 && (l <= 0 || l >= 1) {
+			return fmt.Errorf("ewma lambda must be between 0 and 1 (got %v)", l)
+		}
+		if c.Engine.EWMA.Warmup < 2 {
+			return fmt.Errorf("ewma warmup must be at least 2 returns (got %d)", c.Engine.EWMA.Warmup)
+		}
+	case "hayashi_yoshida":
+		if c.Engine.Sampling.Mode != "tick" {
+			return fmt.Errorf("hayashi_yoshida estimator works on raw ticks and needs tick sampling")
+		}
+		if w := c.Engine.HayashiYoshida.WindowSec; w <= 0 {
+			return fmt.Errorf("hayashi_yoshida window_seconds must be positive (got %v)", w)
+		}
+	default:
+		return fmt.Errorf("unknown estimator %q (want sample, ewma or hayashi_yoshida)", c.Engine.Estimator)
+	}
+
+	if c.Recording.Enabled {
+		if c.Recording.Dir == "" {
+			return fmt.Errorf("recording dir must be set")
+		}
+		if c.Recording.MaxMB < 1 || c.Recording.MaxMinutes < 1 {
+			return fmt.Errorf("recording max_mb and max_minutes must be positive")
+		}
+	}
+
 	if c.Alerts.Correlation < 0 || c.Alerts.Correlation > 1 {
 		return fmt.Errorf("correlation_threshold must be 0-1 (got %.2f)", c.Alerts.Correlation)
 	}
@@ -110,6 +488,38 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("eigenvalue_threshold must be positive (got %.2f)", c.Alerts.Eigenvalue)
 	}
 
+	if c.Engine.BarsPerYear < 0 {
+		return fmt.Errorf("bars_per_year must not be negative (got %v)", c.Engine.BarsPerYear)
+	}
+
+	if c.Alerts.Volatility < 0 {
+		return fmt.Errorf("volatility_threshold must not be negative (got %.2f)", c.Alerts.Volatility)
+	}
+
+	if c.Alerts.AbsorptionRatio < 0 || c.Alerts.AbsorptionRatio > 1 {
+		return fmt.Errorf("absorption_ratio_threshold must be 0-1 (got %.2f)", c.Alerts.AbsorptionRatio)
+	}
+
+	if c.Alerts.AbsorptionShift < 0 {
+		return fmt.Errorf("absorption_shift_threshold must not be negative (got %.2f)", c.Alerts.AbsorptionShift)
+	}
+
+	if c.Alerts.Entropy < 0 || c.Alerts.Entropy > 1 {
+		return fmt.Errorf("entropy_threshold must be 0-1 (got %.2f)", c.Alerts.Entropy)
+	}
+
+	if c.Alerts.MinClusters < 0 {
+		return fmt.Errorf("min_clusters must not be negative (got %d)", c.Alerts.MinClusters)
+	}
+
+	if c.Alerts.TreeLength < 0 {
+		return fmt.Errorf("tree_length_threshold must not be negative (got %.2f)", c.Alerts.TreeLength)
+	}
+
+	if c.Alerts.LeadLag < 0 || c.Alerts.LeadLag > 1 {
+		return fmt.Errorf("lead_lag_threshold must be 0-1 (got %.2f)", c.Alerts.LeadLag)
+	}
+
 	if c.Persistence.Interval < 1 {
 		return fmt.Errorf("persistence interval must be positive (got %d)", c.Persistence.Interval)
 	}
@@ -0,0 +1,379 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Feed selects the market data source. Type names a registered source;
+// the matching sub-section carries that source's options. Name identifies
+// the feed among a merge's sources.
+type Feed struct {
+	Type      string        `yaml:"type"`
+	Name      string        `yaml:"name"`
+	Simulated SimulatedFeed `yaml:"simulated"`
+	CSV       CSVFeed       `yaml:"csv"`
+	Session   SessionFeed   `yaml:"session"`
+	WebSocket WebSocketFeed `yaml:"websocket"`
+	Listener  ListenerFeed  `yaml:"listener"`
+	FIX       FIXFeed       `yaml:"fix"`
+	Merge     MergeFeed     `yaml:"merge"`
+}
+
+// SimulatedFeed configures the synthetic generator. Mode is "sine" or
+// "gbm"; in gbm mode Correlation is the target correlation matrix in
+// symbol order (identity when omitted) and Assets holds per-symbol
+// starting price and annualized drift and volatility. A non-zero Seed
+// makes runs repeatable; Clock "virtual" runs on simulated time from
+// StartTime instead of waiting on the wall clock.
+type SimulatedFeed struct {
+	IntervalMs  int                       `yaml:"interval_ms"`
+	Mode        string                    `yaml:"mode"`
+	Seed        int64                     `yaml:"seed"`
+	Clock       string                    `yaml:"clock"`
+	StartTime   time.Time                 `yaml:"start_time"`
+	Correlation [][]float64               `yaml:"correlation"`
+	Assets      map[string]SimulatedAsset `yaml:"assets"`
+	Scenario    Scenario                  `yaml:"scenario"`
+}
+
+type SimulatedAsset struct {
+	Price      float64 `yaml:"price"`
+	Drift      float64 `yaml:"drift"`
+	Volatility float64 `yaml:"volatility"`
+}
+
+// Scenario scripts timed phases on top of the gbm model. After the last
+// phase the base model resumes, unless Loop restarts the script.
+type Scenario struct {
+	Loop   bool            `yaml:"loop"`
+	Phases []ScenarioPhase `yaml:"phases"`
+}
+
+// ScenarioPhase overrides the model for DurationSec seconds. Correlation,
+// when set, replaces the base matrix with a uniform one, and
+// CorrelationMatrix with a full one in symbol order; VolMultiplier
+// scales every volatility (0 means unchanged). JumpIntensity adds random
+// jumps per symbol per second with normally distributed log sizes, and
+// Jumps schedules fixed moves at offsets into the phase.
+type ScenarioPhase struct {
+	Name              string          `yaml:"name"`
+	DurationSec       float64         `yaml:"duration_seconds"`
+	Correlation       *float64        `yaml:"correlation"`
+	CorrelationMatrix [][]float64     `yaml:"correlation_matrix"`
+	VolMultiplier     float64         `yaml:"vol_multiplier"`
+	JumpIntensity     float64         `yaml:"jump_intensity"`
+	JumpMean          float64         `yaml:"jump_mean"`
+	JumpStd           float64         `yaml:"jump_std"`
+	Jumps             []ScheduledJump `yaml:"jumps"`
+}
+
+// ScheduledJump moves Symbol by the fraction Size (e.g. -0.2 for a 20%
+// drop) AtSec seconds into its phase, which must be before the phase
+// ends.
+type ScheduledJump struct {
+	Symbol string  `yaml:"symbol"`
+	AtSec  float64 `yaml:"at_seconds"`
+	Size   float64 `yaml:"size"`
+}
+
+// CSVFeed replays historical data. Files may contain glob patterns.
+// TimeFormat is "unix", "unix_ms", "unix_ns" or a Go time layout; Speed
+// is a playback multiplier where 1 is real time and 0 is unpaced.
+type CSVFeed struct {
+	Files      []string   `yaml:"files"`
+	Columns    CSVColumns `yaml:"columns"`
+	TimeFormat string     `yaml:"time_format"`
+	Speed      float64    `yaml:"speed"`
+}
+
+// CSVColumns maps tick fields to header names. Leaving Symbol empty takes
+// the symbol from each file's name; Volume and the bar columns Open, High
+// and Low are optional. For bar files point Price at the close.
+type CSVColumns struct {
+	Symbol    string `yaml:"symbol"`
+	Timestamp string `yaml:"timestamp"`
+	Price     string `yaml:"price"`
+	Volume    string `yaml:"volume"`
+	Open      string `yaml:"open"`
+	High      string `yaml:"high"`
+	Low       string `yaml:"low"`
+}
+
+// SessionFeed replays recorded session files at the given speed
+// multiplier (1 is the original timing, 0 is unpaced).
+type SessionFeed struct {
+	Files []string `yaml:"files"`
+	Speed float64  `yaml:"speed"`
+}
+
+// WebSocketFeed connects to a JSON market data stream. Subscribe is a
+// text/template rendered with .Symbols (and a json function) and sent
+// after every connect. TimeFormat follows CSVFeed; without a timestamp
+// field ticks are stamped on receipt.
+type WebSocketFeed struct {
+	URL              string            `yaml:"url"`
+	Headers          map[string]string `yaml:"headers"`
+	Subscribe        string            `yaml:"subscribe"`
+	Fields           WebSocketFields   `yaml:"fields"`
+	TimeFormat       string            `yaml:"time_format"`
+	HeartbeatSec     int               `yaml:"heartbeat_seconds"`
+	IdleTimeoutSec   int               `yaml:"idle_timeout_seconds"`
+	BackoffInitialMs int               `yaml:"backoff_initial_ms"`
+	BackoffMaxMs     int               `yaml:"backoff_max_ms"`
+}
+
+// WebSocketFields are dotted paths into each message ("data.0.p").
+// Items, when set, points at an array of tick objects within a message.
+type WebSocketFields struct {
+	Items     string `yaml:"items"`
+	Symbol    string `yaml:"symbol"`
+	Price     string `yaml:"price"`
+	Volume    string `yaml:"volume"`
+	Timestamp string `yaml:"timestamp"`
+}
+
+// ListenerFeed accepts pushed ticks on TCP and/or UDP addresses (either
+// may be empty). TimeFormat follows CSVFeed.
+type ListenerFeed struct {
+	TCPAddr    string `yaml:"tcp_addr"`
+	UDPAddr    string `yaml:"udp_addr"`
+	TimeFormat string `yaml:"time_format"`
+}
+
+// FIXFeed connects to a FIX 4.4 market data acceptor at Addr.
+// PriceSource is "mid" (bid/offer midpoint) or "trade" (last trade).
+// Only the top of book is kept, so MarketDepth must be 1.
+type FIXFeed struct {
+	Addr         string `yaml:"addr"`
+	SenderCompID string `yaml:"sender_comp_id"`
+	TargetCompID string `yaml:"target_comp_id"`
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	HeartbeatSec int    `yaml:"heartbeat_seconds"`
+	ResetSeqNum  bool   `yaml:"reset_seq_num"`
+	MarketDepth  int    `yaml:"market_depth"`
+	PriceSource  string `yaml:"price_source"`
+	ReconnectSec int    `yaml:"reconnect_seconds"`
+}
+
+// MergeFeed runs several named sources at once. For each symbol, ticks
+// come from the first source in its Priority list (or, for symbols not
+// listed, in Sources order) that is connected and has delivered that
+// symbol within StaleMs. A source that recovers takes a symbol back only
+// after it has stayed healthy for another StaleMs.
+type MergeFeed struct {
+	Sources  []Feed              `yaml:"sources"`
+	Priority map[string][]string `yaml:"priority"`
+	StaleMs  int                 `yaml:"stale_ms"`
+}
+
+// defaultFeed returns the feed defaults. Nested merge sources start from
+// these too, see UnmarshalYAML.
+func defaultFeed() Feed {
+	return Feed{
+		Type: "simulated",
+		Simulated: SimulatedFeed{
+			IntervalMs: 25,
+			Mode:       "sine",
+			Clock:      "real",
+		},
+		CSV: CSVFeed{
+			Columns: CSVColumns{
+				Symbol:    "symbol",
+				Timestamp: "timestamp",
+				Price:     "price",
+				Volume:    "volume",
+				Open:      "open",
+				High:      "high",
+				Low:       "low",
+			},
+			TimeFormat: time.RFC3339,
+			Speed:      1,
+		},
+		Session: SessionFeed{
+			Speed: 1,
+		},
+		WebSocket: WebSocketFeed{
+			Fields: WebSocketFields{
+				Symbol:    "symbol",
+				Price:     "price",
+				Volume:    "volume",
+				Timestamp: "timestamp",
+			},
+			TimeFormat:       "unix_ms",
+			HeartbeatSec:     15,
+			IdleTimeoutSec:   45,
+			BackoffInitialMs: 500,
+			BackoffMaxMs:     30000,
+		},
+		Listener: ListenerFeed{
+			TimeFormat: "unix_ns",
+		},
+		FIX: FIXFeed{
+			HeartbeatSec: 30,
+			ResetSeqNum:  true,
+			MarketDepth:  1,
+			PriceSource:  "mid",
+			ReconnectSec: 5,
+		},
+		Merge: MergeFeed{
+			StaleMs: 2000,
+		},
+	}
+}
+
+// UnmarshalYAML decodes a feed section over the defaults, so that every
+// feed, including each of a merge's sources, only needs to spell out
+// what it changes.
+func (f *Feed) UnmarshalYAML(node *yaml.Node) error {
+	type plain Feed
+	p := plain(defaultFeed())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*f = Feed(p)
+	return nil
+}
+
+func (f *Feed) validate(nSymbols int) error {
+	if f.Type == "" {
+		return fmt.Errorf("feed type must be set")
+	}
+
+	if f.Simulated.IntervalMs < 1 {
+		return fmt.Errorf("simulated feed interval_ms must be positive (got %d)", f.Simulated.IntervalMs)
+	}
+
+	if m := f.Simulated.Mode; m != "sine" && m != "gbm" {
+		return fmt.Errorf("simulated feed mode must be sine or gbm (got %q)", m)
+	}
+
+	if cl := f.Simulated.Clock; cl != "real" && cl != "virtual" {
+		return fmt.Errorf("simulated feed clock must be real or virtual (got %q)", cl)
+	}
+
+	if n := len(f.Simulated.Correlation); n > 0 && n != nSymbols {
+		return fmt.Errorf("simulated correlation matrix must be %dx%d (got %d rows)", nSymbols, nSymbols, n)
+	}
+
+	for sym, a := range f.Simulated.Assets {
+		if a.Price <= 0 || a.Volatility < 0 {
+			return fmt.Errorf("simulated asset %s needs a positive price and non-negative volatility", sym)
+		}
+	}
+
+	for _, p := range f.Simulated.Scenario.Phases {
+		if f.Simulated.Mode != "gbm" {
+			return fmt.Errorf("simulated scenarios require gbm mode")
+		}
+		if p.DurationSec <= 0 {
+			return fmt.Errorf("scenario phase %q needs a positive duration_seconds", p.Name)
+		}
+		if p.Correlation != nil && len(p.CorrelationMatrix) > 0 {
+			return fmt.Errorf("scenario phase %q sets both correlation and correlation_matrix", p.Name)
+		}
+		if n := len(p.CorrelationMatrix); n > 0 && n != nSymbols {
+			return fmt.Errorf("scenario phase %q correlation matrix must be %dx%d (got %d rows)", p.Name, nSymbols, nSymbols, n)
+		}
+		if p.VolMultiplier < 0 || p.JumpIntensity < 0 || p.JumpStd < 0 {
+			return fmt.Errorf("scenario phase %q has a negative vol_multiplier, jump_intensity or jump_std", p.Name)
+		}
+		for _, j := range p.Jumps {
+			if j.Size <= -1 {
+				return fmt.Errorf("scenario phase %q: jump on %s must be greater than -1 (got %.2f)", p.Name, j.Symbol, j.Size)
+			}
+			if j.AtSec < 0 || j.AtSec >= p.DurationSec {
+				return fmt.Errorf("scenario phase %q: jump on %s at_seconds must be in [0, %g) (got %g)", p.Name, j.Symbol, p.DurationSec, j.AtSec)
+			}
+		}
+	}
+
+	if f.Type == "csv" && len(f.CSV.Files) == 0 {
+		return fmt.Errorf("csv feed requires at least one file")
+	}
+
+	if f.CSV.Speed < 0 {
+		return fmt.Errorf("csv feed speed must not be negative (got %.2f)", f.CSV.Speed)
+	}
+
+	if f.Type == "session" && len(f.Session.Files) == 0 {
+		return fmt.Errorf("session feed requires at least one file")
+	}
+
+	if f.Session.Speed < 0 {
+		return fmt.Errorf("session feed speed must not be negative (got %.2f)", f.Session.Speed)
+	}
+
+	if f.Type == "websocket" {
+		ws := f.WebSocket
+		if ws.URL == "" {
+			return fmt.Errorf("websocket feed requires a url")
+		}
+		if ws.Fields.Symbol == "" || ws.Fields.Price == "" {
+			return fmt.Errorf("websocket feed requires symbol and price field paths")
+		}
+		if ws.IdleTimeoutSec < 1 || ws.HeartbeatSec < 0 {
+			return fmt.Errorf("websocket idle_timeout_seconds must be positive and heartbeat_seconds non-negative")
+		}
+		if ws.BackoffInitialMs < 1 || ws.BackoffMaxMs < ws.BackoffInitialMs {
+			return fmt.Errorf("websocket backoff must satisfy 0 < backoff_initial_ms <= backoff_max_ms")
+		}
+	}
+
+	if f.Type == "listener" && f.Listener.TCPAddr == "" && f.Listener.UDPAddr == "" {
+		return fmt.Errorf("listener feed requires tcp_addr or udp_addr")
+	}
+
+	if f.Type == "fix" {
+		fx := f.FIX
+		if fx.Addr == "" || fx.SenderCompID == "" || fx.TargetCompID == "" {
+			return fmt.Errorf("fix feed requires addr, sender_comp_id and target_comp_id")
+		}
+		if fx.HeartbeatSec < 1 || fx.ReconnectSec < 1 {
+			return fmt.Errorf("fix heartbeat_seconds and reconnect_seconds must be positive")
+		}
+		if fx.MarketDepth != 1 {
+			return fmt.Errorf("fix market_depth must be 1, the feed keeps only the top of book (got %d)", fx.MarketDepth)
+		}
+		if fx.PriceSource != "mid" && fx.PriceSource != "trade" {
+			return fmt.Errorf("fix price_source must be mid or trade (got %q)", fx.PriceSource)
+		}
+	}
+
+	if f.Type == "merge" {
+		if len(f.Merge.Sources) < 2 {
+			return fmt.Errorf("merge feed needs at least two sources")
+		}
+		if f.Merge.StaleMs < 1 {
+			return fmt.Errorf("merge stale_ms must be positive (got %d)", f.Merge.StaleMs)
+		}
+
+		names := make(map[string]bool, len(f.Merge.Sources))
+		for i := range f.Merge.Sources {
+			src := &f.Merge.Sources[i]
+			if src.Name == "" || names[src.Name] {
+				return fmt.Errorf("merge sources need unique names (source %d)", i+1)
+			}
+			if src.Type == "merge" {
+				return fmt.Errorf("merge source %s cannot itself be a merge", src.Name)
+			}
+			if err := src.validate(nSymbols); err != nil {
+				return fmt.Errorf("merge source %s: %w", src.Name, err)
+			}
+			names[src.Name] = true
+		}
+
+		for sym, order := range f.Merge.Priority {
+			for _, name := range order {
+				if !names[name] {
+					return fmt.Errorf("merge priority for %s names unknown source %q", sym, name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package display
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -201,6 +203,22 @@ func (g *GUI) updateRegime() {
 		eigenText += fmt.Sprintf("%.3f ", mode.Eigenvalues[i])
 	}
 
+	if mat := g.eng.Matrix(); mat != nil && len(mode.Explained) > 0 && len(mode.Loadings) == len(mat.Symbols) {
+		eigenText += fmt.Sprintf("\nMarket Mode: %.1f%% of variance  |  Drivers: ", 100*mode.Explained[0])
+
+		order := make([]int, len(mat.Symbols))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool {
+			return math.Abs(mode.Loadings[order[a]][0]) > math.Abs(mode.Loadings[order[b]][0])
+		})
+
+		for i := 0; i < len(order) && i < 3; i++ {
+			eigenText += fmt.Sprintf("%s %.2f  ", mat.Symbols[order[i]], mode.Loadings[order[i]][0])
+		}
+	}
+
 	g.eigenLabel.SetText(eigenText)
 }
 
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"time"
+
+	"matrixpulse/internal/cluster"
+	"matrixpulse/internal/types"
+)
+
+// computeClusters runs each configured linkage over the correlation
+// matrix and cuts the dendrograms into flat clusters. When the first
+// linkage's cluster count falls below the configured minimum, the
+// universe is collapsing into one block and an alert is raised once per
+// crossing.
+func (e *Engine) computeClusters(cor [][]float64) {
+	cfg := e.clustering
+	n := len(cor)
+	dist := cluster.Distances(cor, cfg.Distance)
+	now := time.Now()
+
+	out := make([]types.Clustering, 0, len(cfg.Linkages))
+	for _, linkage := range cfg.Linkages {
+		merges := cluster.Build(dist, linkage)
+
+		var labels []int
+		if cfg.Clusters > 0 {
+			labels = cluster.CutCount(merges, n, cfg.Clusters)
+		} else {
+			labels = cluster.Cut(merges, n, cfg.CutHeight)
+		}
+		count := 0
+		for _, l := range labels {
+			if l+1 > count {
+				count = l + 1
+			}
+		}
+
+		out = append(out, types.Clustering{
+			Linkage: linkage,
+			Merges:  merges,
+			Labels:  labels,
+			Count:   count,
+			Order:   cluster.Order(merges, n),
+			Time:    now,
+		})
+	}
+
+	if t := e.cfg.MinClusters; t > 0 && len(out) > 0 {
+		low := out[0].Count < t
+		if low && !e.clustersLow {
+			e.addAlert(types.Alert{
+				Level:     "HIGH",
+				Symbol:    "MARKET",
+				Message:   "clusters merging (" + out[0].Linkage + " linkage)",
+				Value:     float64(out[0].Count),
+				Threshold: float64(t),
+				Time:      now,
+			})
+		}
+		e.clustersLow = low
+	}
+
+	e.mu.Lock()
+	e.clusters = out
+	e.mu.Unlock()
+}
+
+// Clusters returns the clusterings from the last Compute, one per
+// configured linkage, or nil when clustering is off.
+func (e *Engine) Clusters() []types.Clustering {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]types.Clustering(nil), e.clusters...)
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"math"
+
+	m "matrixpulse/internal/math"
+)
+
+// correlation builds the correlation matrix for method. Pearson is read
+// off the covariance estimate, already shrunk; the rank measures work on
+// the aligned returns in the windows and are shrunk here with the same
+// intensity, so every measure reflects the reported Shrinkage.
+func (e *Engine) correlation(method string, cov [][]float64, intensity float64) [][]float64 {
+	switch method {
+	case "spearman", "kendall":
+		cor := e.rankCor(method)
+		if cor != nil && intensity > 0 {
+			cor = e.shrinkTo(cor, intensity)
+		}
+		return cor
+	default:
+		return pearsonFromCov(cov)
+	}
+}
+
+func pearsonFromCov(cov [][]float64) [][]float64 {
+	n := len(cov)
+	cor := newSquare(n)
+	for i := 0; i < n; i++ {
+		cor[i][i] = 1.0
+		for j := i + 1; j < n; j++ {
+			if cov[i][i] > 0 && cov[j][j] > 0 {
+				r := cov[i][j] / math.Sqrt(cov[i][i]*cov[j][j])
+				cor[i][j] = r
+				cor[j][i] = r
+			}
+		}
+	}
+	return cor
+}
+
+// rankCor applies a rank measure to the log returns of every pair of
+// windows, aligned from the newest end. Spearman ranks each series once
+// up front, so its pairs reduce to Pearson on ranks and the matrix is
+// positive semi-definite. Pairwise Kendall taus need not be, which would
+// give negative eigenvalues downstream, so that matrix is projected onto
+// the nearest PSD one.
+func (e *Engine) rankCor(method string) [][]float64 {
+	returns := e.alignedReturns()
+	if returns == nil {
+		return nil
+	}
+
+	n := len(returns)
+	cor := newSquare(n)
+	for i := 0; i < n; i++ {
+		cor[i][i] = 1.0
+	}
+
+	pair := m.Kendall
+	if method == "spearman" {
+		for i := range returns {
+			returns[i] = m.Ranks(returns[i])
+		}
+		pair = m.Pearson
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			r := pair(returns[i], returns[j])
+			cor[i][j] = r
+			cor[j][i] = r
+		}
+	}
+	if method == "kendall" {
+		cor = m.NearestPSD(cor)
+	}
+	return cor
+}
+
+// alignedReturns returns every window's log returns cut to a common
+// length from the newest end, or nil if some window has fewer than three
+// prices.
+func (e *Engine) alignedReturns() [][]float64 {
+	returns := make([][]float64, len(e.symbols))
+	shortest := 0
+	for i, sym := range e.symbols {
+		prices := e.windows[sym].Snapshot()
+		if len(prices) < 3 {
+			return nil
+		}
+		returns[i] = m.LogReturns(prices)
+		if i == 0 || len(returns[i]) < shortest {
+			shortest = len(returns[i])
+		}
+	}
+	for i := range returns {
+		returns[i] = returns[i][len(returns[i])-shortest:]
+	}
+	return returns
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	m "matrixpulse/internal/math"
+	"matrixpulse/internal/types"
+)
+
+// TestRankShrinkage checks that the rank measures are shrunk with the
+// intensity the matrix reports, and that Kendall comes out PSD.
+func TestRankShrinkage(t *testing.T) {
+	syms := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+	e := New(syms, 12, config.Alerts{}, config.Engine{
+		Correlation: "kendall",
+		Also:        []string{"spearman"},
+		Shrinkage:   "ledoit_wolf",
+	})
+	rng := rand.New(rand.NewSource(5))
+	t0 := time.Unix(1700000000, 0)
+	prices := make([]float64, len(syms))
+	for k := 0; k < 12; k++ {
+		common := rng.NormFloat64()
+		for i, sym := range syms {
+			if k == 0 {
+				prices[i] = 100
+			}
+			prices[i] *= math.Exp(0.01 * (common + rng.NormFloat64()))
+			e.Ingest(types.Tick{Symbol: sym, Price: prices[i], Time: t0.Add(time.Duration(k) * time.Second)})
+		}
+	}
+	e.Compute()
+	mat := e.Matrix()
+	if mat == nil || mat.Shrinkage <= 0 || mat.Shrinkage >= 1 {
+		t.Fatalf("matrix %+v, want a shrinkage intensity in (0, 1)", mat)
+	}
+
+	returns := e.alignedReturns()
+	raw := func(pair func(x, y []float64) float64) [][]float64 {
+		out := newSquare(len(syms))
+		for i := range out {
+			for j := range out {
+				out[i][j] = pair(returns[i], returns[j])
+			}
+		}
+		return out
+	}
+	identity := newSquare(len(syms))
+	for i := range identity {
+		identity[i][i] = 1
+	}
+	for _, tc := range []struct {
+		name string
+		got  [][]float64
+		want [][]float64
+	}{
+		{"kendall", mat.Cor, m.ShrinkTo(m.NearestPSD(raw(m.Kendall)), identity, mat.Shrinkage)},
+		{"spearman", mat.Alt["spearman"], m.ShrinkTo(raw(m.Spearman), identity, mat.Shrinkage)},
+	} {
+		covClose(t, tc.name, 0, tc.got, tc.want)
+	}
+
+	vals, _, ok := m.EigenSym(mat.Cor)
+	if !ok || vals[len(vals)-1] < 0 {
+		t.Errorf("Kendall eigenvalues %v, want none negative", vals)
+	}
+}
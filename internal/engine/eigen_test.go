@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/math/mathtest"
+	"matrixpulse/internal/types"
+)
+
+// withMatrix fills every window with winSize prices, so the estimate
+// rests on winSize-1 returns, and publishes cor as the latest matrix.
+func withMatrix(e *Engine, cor [][]float64) {
+	t0 := time.Unix(1700000000, 0)
+	for k := 0; k < e.winSize; k++ {
+		for _, sym := range e.symbols {
+			e.Ingest(types.Tick{Symbol: sym, Price: 100 + float64(k), Time: t0.Add(time.Duration(k) * time.Second)})
+		}
+	}
+	e.matrix = &types.Matrix{Cov: cor, Cor: cor, Symbols: e.symbols}
+}
+
+func TestRMTNoiseBand(t *testing.T) {
+	syms := []string{"A", "B", "C", "D"}
+	spectrum := []float64{2, 1.5, 0.3, 0.2}
+	for _, tc := range []struct {
+		name    string
+		winSize int
+		rmt     config.RMT
+		signals int
+		regime  string
+	}{
+		// T = 99: λ+ = (1 + √(4/99))² ≈ 1.44, so 2 and 1.5 are signal.
+		{"long window", 100, config.RMT{Enabled: true}, 2, "NORMAL"},
+		// T = 19: λ+ ≈ 2.13 and nothing stands out from noise.
+		{"short window", 20, config.RMT{Enabled: true}, 0, "NORMAL"},
+		// A crisis at 1.2 λ+ ≈ 1.73 is below the top eigenvalue of 2.
+		{"crisis multiple", 100, config.RMT{Enabled: true, CrisisMultiple: 1.2}, 2, "CRISIS"},
+		{"crisis multiple, short window", 20, config.RMT{Enabled: true, CrisisMultiple: 1.2}, 0, "NORMAL"},
+	} {
+		e := New(syms, tc.winSize, config.Alerts{Eigenvalue: 3}, config.Engine{RMT: tc.rmt})
+		withMatrix(e, mathtest.WithSpectrum(spectrum))
+		e.computeEigen()
+
+		mode := e.Mode()
+		q := math.Sqrt(4 / float64(tc.winSize-1))
+		if bound := (1 + q) * (1 + q); math.Abs(mode.NoiseBound-bound) > 1e-12 {
+			t.Errorf("%s: NoiseBound = %v, want %v", tc.name, mode.NoiseBound, bound)
+		}
+		if mode.Signals != tc.signals || mode.Regime != tc.regime {
+			t.Errorf("%s: %d signals, regime %s, want %d, %s", tc.name, mode.Signals, mode.Regime, tc.signals, tc.regime)
+		}
+	}
+}
+
+func TestRMTCleaned(t *testing.T) {
+	e := New([]string{"A", "B", "C", "D"}, 100, config.Alerts{Eigenvalue: 3}, config.Engine{
+		RMT: config.RMT{Enabled: true, Clean: true},
+	})
+	withMatrix(e, mathtest.WithSpectrum([]float64{2, 1.5, 0.3, 0.2}))
+	e.computeEigen()
+
+	// 0.3 and 0.2 lie inside the band and become their average.
+	want := mathtest.WithSpectrum([]float64{2, 1.5, 0.25, 0.25})
+	covClose(t, "cleaned", 0, e.Matrix().Cleaned, want)
+
+	off := New([]string{"A", "B", "C", "D"}, 100, config.Alerts{Eigenvalue: 3}, config.Engine{RMT: config.RMT{Enabled: true}})
+	withMatrix(off, mathtest.WithSpectrum([]float64{2, 1.5, 0.3, 0.2}))
+	off.computeEigen()
+	if off.Matrix().Cleaned != nil {
+		t.Error("cleaned matrix published with rmt.clean off")
+	}
+}
+
+func TestPCA(t *testing.T) {
+	spectrum := []float64{2, 1.5, 0.3, 0.2}
+	e := New([]string{"A", "B", "C", "D"}, 100, config.Alerts{Eigenvalue: 3}, config.Engine{PCA: config.PCA{Components: 2}})
+	withMatrix(e, mathtest.WithSpectrum(spectrum))
+	e.computeEigen()
+
+	mode := e.Mode()
+	for k, v := range spectrum {
+		if math.Abs(mode.Eigenvalues[k]-v) > 1e-12 || math.Abs(mode.Explained[k]-v/4) > 1e-12 {
+			t.Fatalf("eigenvalues %v, explained %v, want %v and a quarter of each", mode.Eigenvalues, mode.Explained, spectrum)
+		}
+	}
+	if mode.MaxEigen != mode.Eigenvalues[0] || math.Abs(mode.Condition-10) > 1e-9 {
+		t.Errorf("MaxEigen %v, Condition %v, want 2 and 10", mode.MaxEigen, mode.Condition)
+	}
+
+	// Every symbol has weight 1/2 in each component, so its loading is
+	// ±√λ/2: its correlation with the component.
+	for i, row := range mode.Loadings {
+		if len(row) != 2 {
+			t.Fatalf("symbol %d has %d loadings, want 2", i, len(row))
+		}
+		for c, l := range row {
+			if want := math.Sqrt(spectrum[c]) / 2; math.Abs(math.Abs(l)-want) > 1e-12 {
+				t.Errorf("loading[%d][%d] = %v, want ±%v", i, c, l, want)
+			}
+		}
+		// The market mode loads positively on every symbol.
+		if row[0] < 0 {
+			t.Errorf("symbol %d loads %v on the market mode", i, row[0])
+		}
+	}
+}
@@ -10,100 +10,404 @@ import (
 	m "matrixpulse/internal/math"
 	"matrixpulse/internal/types"
 	"matrixpulse/internal/window"
-
-	"gonum.org/v1/gonum/mat"
 )
 
 type Engine struct {
 	symbols []string
 	windows map[string]*window.Rolling
-	matrix  *types.Matrix
-	mode    *types.Mode
-	alerts  []types.Alert
-	cfg     config.Alerts
-	mu      sync.RWMutex
+	winSize int
+	sampler *sampler
+
+	// estimator names the covariance estimator Compute uses; ewma and
+	// online hold the state of the incremental ones, which rows feeds
+	// with aligned returns as prices arrive, and hy the tick history of
+	// the Hayashi–Yoshida one. shrinkage names the target the sample
+	// covariance is pulled towards.
+	estimator string
+	hy        *tickHistory
+	ewma      *ewma
+	online    *onlineCov
+	rows      *rowBuilder
+	shrinkage string
+
+	// method is the correlation measure published in Cor and fed to the
+	// eigen analysis; also lists measures computed alongside it.
+	method string
+	also   []string
+
+	// precMode selects the precision matrix output; glasso keeps its
+	// previous fit as a warm start and glassoWarned keeps a
+	// non-converging lasso from logging on every update.
+	precMode     string
+	glasso       *m.Glasso
+	glassoWarned bool
+
+	// rmt configures noise filtering of the eigenvalues, components is
+	// how many principal components Mode.Loadings covers and absorption
+	// tracks the absorption ratio's history. ratioHigh, shiftHigh and
+	// entropyLow mark the systemic indicators past their thresholds.
+	rmt        config.RMT
+	components int
+	absorption *absorption
+	ratioHigh  bool
+	shiftHigh  bool
+	entropyLow bool
+
+	// clustering configures hierarchical clustering; clustersLow marks
+	// the cluster count below its alert threshold.
+	clustering  config.Clustering
+	clustersLow bool
+	clusters    []types.Clustering
+
+	// netCfg configures the correlation network; treeLengths is the
+	// history its length is compared against and treeShort marks the
+	// length below its alert threshold. betweenHist and degreeHist hold
+	// each symbol's centrality history, indexed like symbols.
+	netCfg      config.Network
+	treeLengths *window.Rolling
+	treeShort   bool
+	betweenHist []*window.Rolling
+	degreeHist  []*window.Rolling
+	network     *types.Network
+
+	// leadLag configures lagged cross-correlation; leadLagStates tracks
+	// each pair's peak lag across updates, keyed by i*n+j, and
+	// leadLagSeq is the windows' total push count it last saw.
+	leadLag       config.LeadLag
+	leadLagStates map[int]*leadLagState
+	leadLagSeq    uint64
+	leadLags      []types.LeadLag
+
+	// returns keeps each symbol's return sums as prices enter its window;
+	// bars is created per symbol on its first OHLC tick. volHigh marks
+	// symbols above the volatility threshold so alerts fire on crossing.
+	// barsPerYear fixes the annualization factor when positive.
+	returns     map[string]*returnStats
+	bars        map[string]*bars
+	volHigh     map[string]bool
+	stats       []types.SymbolStats
+	barsPerYear float64
+
+	matrix *types.Matrix
+	mode   *types.Mode
+	alerts []types.Alert
+	cfg    config.Alerts
+	mu     sync.RWMutex
 }
 
-func New(symbols []string, winSize int, cfg config.Alerts) *Engine {
+// New builds an engine for symbols with windows of winSize samples.
+// Settings in opts left at their zero value take config.DefaultEngine's.
+func New(symbols []string, winSize int, cfg config.Alerts, opts config.Engine) *Engine {
+	opts = withDefaults(opts)
+
 	wins := make(map[string]*window.Rolling, len(symbols))
+	returns := make(map[string]*returnStats, len(symbols))
 	for _, sym := range symbols {
 		wins[sym] = window.New(winSize)
+		returns[sym] = newReturnStats(winSize)
 	}
 
-	return &Engine{
-		symbols: symbols,
-		windows: wins,
-		alerts:  make([]types.Alert, 0, 100),
-		cfg:     cfg,
+	e := &Engine{
+		symbols:    symbols,
+		windows:    wins,
+		winSize:    winSize,
+		estimator:  opts.Estimator,
+		shrinkage:  opts.Shrinkage,
+		method:     opts.Correlation,
+		also:       opts.Also,
+		precMode:   opts.Precision.Mode,
+		rmt:        opts.RMT,
+		components: opts.PCA.Components,
+		absorption: newAbsorption(opts.Absorption),
+		clustering: opts.Clustering,
+		netCfg:     opts.Network,
+		leadLag:    opts.LeadLag,
+		returns:    returns,
+		bars:       make(map[string]*bars, len(symbols)),
+		volHigh:    make(map[string]bool, len(symbols)),
+		alerts:     make([]types.Alert, 0, 100),
+		cfg:        cfg,
+	}
+	e.barsPerYear = opts.BarsPerYear
+
+	if opts.Precision.Mode == "glasso" {
+		e.glasso = &m.Glasso{
+			Lambda:  opts.Precision.Lambda,
+			MaxIter: opts.Precision.MaxIter,
+			Tol:     opts.Precision.Tolerance,
+		}
+	}
+	if opts.LeadLag.Enabled {
+		e.leadLagStates = make(map[int]*leadLagState)
+	}
+	if opts.Network.Enabled {
+		e.treeLengths = window.New(opts.Network.History)
+		e.betweenHist = make([]*window.Rolling, len(symbols))
+		e.degreeHist = make([]*window.Rolling, len(symbols))
+		for i := range symbols {
+			e.betweenHist[i] = window.New(opts.Network.History)
+			e.degreeHist[i] = window.New(opts.Network.History)
+		}
+	}
+	if opts.Incremental || opts.Estimator == "ewma" {
+		e.rows = newRowBuilder(symbols)
 	}
+	if opts.Incremental {
+		e.online = newOnlineCov(len(symbols), winSize)
+		e.rows.feed(e.online.push)
+	}
+	if opts.Estimator == "ewma" {
+		e.ewma = newEWMA(opts.EWMA)
+		e.rows.feed(e.ewma.push)
+	}
+	if opts.Estimator == "hayashi_yoshida" {
+		span := time.Duration(opts.HayashiYoshida.WindowSec * float64(time.Second))
+		e.hy = newTickHistory(symbols, span)
+	}
+
+	if opts.Sampling.Mode == "grid" {
+		interval := time.Duration(opts.Sampling.IntervalMs) * time.Millisecond
+		e.sampler = newSampler(interval, symbols, winSize, e.push)
+	}
+
+	return e
 }
 
+// withDefaults fills the settings of opts that have no usable zero value
+// from config.DefaultEngine, so callers that skip config.Validate still
+// get a working engine.
+func withDefaults(opts config.Engine) config.Engine {
+	def := config.DefaultEngine()
+
+	if opts.Sampling.Mode == "" {
+		opts.Sampling.Mode = def.Sampling.Mode
+	}
+	if opts.Sampling.IntervalMs <= 0 {
+		opts.Sampling.IntervalMs = def.Sampling.IntervalMs
+	}
+	if opts.Estimator == "" {
+		opts.Estimator = def.Estimator
+	}
+	if opts.EWMA.Lambda == 0 && opts.EWMA.HalfLife == 0 {
+		opts.EWMA.Lambda = def.EWMA.Lambda
+	}
+	if opts.EWMA.Warmup < 2 {
+		opts.EWMA.Warmup = def.EWMA.Warmup
+	}
+	if opts.HayashiYoshida.WindowSec <= 0 {
+		opts.HayashiYoshida.WindowSec = def.HayashiYoshida.WindowSec
+	}
+	if opts.Correlation == "" {
+		opts.Correlation = def.Correlation
+	}
+	if opts.Shrinkage == "" {
+		opts.Shrinkage = def.Shrinkage
+	}
+	if opts.PCA.Components < 1 {
+		opts.PCA.Components = def.PCA.Components
+	}
+
+	a := &opts.Absorption
+	if a.Fraction <= 0 {
+		a.Fraction = def.Absorption.Fraction
+	}
+	if a.ShortSec <= 0 {
+		a.ShortSec = def.Absorption.ShortSec
+	}
+	if a.LongSec < 2*a.ShortSec {
+		a.LongSec = max(def.Absorption.LongSec, 2*a.ShortSec)
+	}
+
+	p := &opts.Precision
+	if p.Mode == "" {
+		p.Mode = def.Precision.Mode
+	}
+	if p.Lambda <= 0 {
+		p.Lambda = def.Precision.Lambda
+	}
+	if p.MaxIter < 1 {
+		p.MaxIter = def.Precision.MaxIter
+	}
+	if p.Tolerance <= 0 {
+		p.Tolerance = def.Precision.Tolerance
+	}
+
+	if len(opts.Clustering.Linkages) == 0 {
+		opts.Clustering.Linkages = def.Clustering.Linkages
+	}
+	if opts.Clustering.Distance == "" {
+		opts.Clustering.Distance = def.Clustering.Distance
+	}
+	if opts.Network.History < 2 {
+		opts.Network.History = def.Network.History
+	}
+	if opts.LeadLag.MaxLag < 1 {
+		opts.LeadLag.MaxLag = def.LeadLag.MaxLag
+	}
+	if opts.LeadLag.StableCycles < 1 {
+		opts.LeadLag.StableCycles = def.LeadLag.StableCycles
+	}
+	return opts
+}
+
+// Ingest adds a tick to its symbol's window, or in grid sampling mode
+// hands it to the sampler, which fills the windows at grid boundaries.
 func (e *Engine) Ingest(tick types.Tick) {
-	if w, ok := e.windows[tick.Symbol]; ok {
-		w.Push(tick.Price)
+	if tick.High > 0 && tick.Low > 0 {
+		e.ingestBar(tick)
+	}
+	if e.sampler != nil {
+		e.sampler.observe(tick)
+		return
+	}
+	if _, ok := e.windows[tick.Symbol]; ok {
+		e.push(tick.Symbol, tick.Price, tick.Time)
+		if e.hy != nil {
+			e.hy.push(tick.Symbol, tick.Price, tick.Time)
+		}
+	}
+}
+
+// push adds a price to sym's window and to the running state fed
+// alongside it: the return sums and, when set, the incremental rows.
+func (e *Engine) push(sym string, price float64, at time.Time) {
+	e.windows[sym].PushAt(price, at)
+	e.returns[sym].push(price, at)
+	if e.rows != nil {
+		e.rows.observe(sym, price)
 	}
 }
 
 func (e *Engine) Compute() {
+	var cov [][]float64
+	switch e.estimator {
+	case "hayashi_yoshida":
+		cov = e.hayashiYoshidaCov()
+	case "ewma":
+		cov = e.ewma.estimate()
+	default:
+		if e.online != nil {
+			cov = e.online.cov()
+		} else {
+			cov = e.sampleCov()
+		}
+	}
+	if cov == nil {
+		return
+	}
+
+	var intensity float64
+	if e.shrinkage != "none" {
+		cov, intensity = e.shrink(cov)
+	}
+
+	cor := e.correlation(e.method, cov, intensity)
+	if cor == nil {
+		return
+	}
+	prec, partial := e.precision(cov)
+
+	var alt map[string][][]float64
+	if len(e.also) > 0 {
+		alt = make(map[string][][]float64, len(e.also))
+		for _, method := range e.also {
+			if c := e.correlation(method, cov, intensity); c != nil {
+				alt[method] = c
+			}
+		}
+	}
+
+	n := len(cor)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if r := cor[i][j]; math.Abs(r) > e.cfg.Correlation {
+				e.addAlert(types.Alert{
+					Level:     "HIGH",
+					Symbol:    e.symbols[i] + "-" + e.symbols[j],
+					Message:   "correlation spike",
+					Value:     r,
+					Threshold: e.cfg.Correlation,
+					Time:      time.Now(),
+				})
+			}
+		}
+	}
+
+	e.mu.Lock()
+	e.matrix = &types.Matrix{
+		Cov:       cov,
+		Cor:       cor,
+		Method:    e.method,
+		Alt:       alt,
+		Shrinkage: intensity,
+		Precision: prec,
+		Partial:   partial,
+		Symbols:   e.symbols,
+		Time:      time.Now(),
+	}
+	e.mu.Unlock()
+
+	e.computeEigen()
+	e.computeStats()
+	if e.clustering.Enabled {
+		e.computeClusters(cor)
+	}
+	if e.netCfg.Enabled {
+		e.computeNetwork(cor)
+	}
+	if e.leadLag.Enabled {
+		e.computeLeadLag()
+	}
+}
+
+func (e *Engine) ingestBar(tick types.Tick) {
+	if _, ok := e.windows[tick.Symbol]; !ok {
+		return
+	}
+	e.mu.Lock()
+	b := e.bars[tick.Symbol]
+	if b == nil {
+		b = newBars(e.winSize)
+		e.bars[tick.Symbol] = b
+	}
+	e.mu.Unlock()
+	b.push(tick)
+}
+
+// sampleCov is the sample covariance of aligned log returns: entry k of
+// every window is taken to be the same moment. It returns nil until each
+// window holds two prices.
+func (e *Engine) sampleCov() [][]float64 {
 	n := len(e.symbols)
 	returns := make([][]float64, n)
 	means := make([]float64, n)
-	stds := make([]float64, n)
 
 	for i, sym := range e.symbols {
 		prices := e.windows[sym].Snapshot()
 		if len(prices) < 2 {
-			return
+			return nil
 		}
 		returns[i] = m.LogReturns(prices)
 		means[i] = m.Mean(returns[i])
-		stds[i] = m.StdDev(returns[i], means[i])
-	}
-
-	cov := make([][]float64, n)
-	cor := make([][]float64, n)
-	for i := range cov {
-		cov[i] = make([]float64, n)
-		cor[i] = make([]float64, n)
 	}
 
+	cov := newSquare(n)
 	for i := 0; i < n; i++ {
 		for j := i; j < n; j++ {
 			c := m.Covariance(returns[i], returns[j], means[i], means[j])
 			cov[i][j] = c
 			cov[j][i] = c
-
-			if i == j {
-				cor[i][j] = 1.0
-			} else if stds[i] > 0 && stds[j] > 0 {
-				r := c / (stds[i] * stds[j])
-				cor[i][j] = r
-				cor[j][i] = r
-
-				if math.Abs(r) > e.cfg.Correlation {
-					e.addAlert(types.Alert{
-						Level:     "HIGH",
-						Symbol:    e.symbols[i] + "-" + e.symbols[j],
-						Message:   "correlation spike",
-						Value:     r,
-						Threshold: e.cfg.Correlation,
-						Time:      time.Now(),
-					})
-				}
-			}
 		}
 	}
+	return cov
+}
 
-	e.mu.Lock()
-	e.matrix = &types.Matrix{
-		Cov:     cov,
-		Cor:     cor,
-		Symbols: e.symbols,
-		Time:    time.Now(),
+func newSquare(n int) [][]float64 {
+	out := make([][]float64, n)
+	for i := range out {
+		out[i] = make([]float64, n)
 	}
-	e.mu.Unlock()
-
-	e.computeEigen()
+	return out
 }
 
 func (e *Engine) computeEigen() {
@@ -112,65 +416,151 @@ func (e *Engine) computeEigen() {
 		e.mu.RUnlock()
 		return
 	}
-	n := len(e.matrix.Cor)
-	flat := make([]float64, n*n)
-	for i := 0; i < n; i++ {
-		for j := 0; j < n; j++ {
-			flat[i*n+j] = e.matrix.Cor[i][j]
-		}
-	}
+	matrix := e.matrix
 	e.mu.RUnlock()
 
-	corMat := mat.NewDense(n, n, flat)
-	var eig mat.Eigen
-	if !eig.Factorize(corMat, mat.EigenRight) {
+	vals, vecs, ok := m.EigenSym(matrix.Cor)
+	if !ok {
 		log.Printf("eigen factorization failed")
 		return
 	}
+	n := len(vals)
 
-	vals := eig.Values(nil)
-	eigenvals := make([]float64, len(vals))
-	maxEigen := 0.0
+	maxEigen := vals[0]
 	minEigen := math.MaxFloat64
+	total := 0.0
+	for _, v := range vals {
+		if v < minEigen && v > 0 {
+			minEigen = v
+		}
+		total += v
+	}
 
-	for i, v := range vals {
-		eigenvals[i] = real(v)
-		if eigenvals[i] > maxEigen {
-			maxEigen = eigenvals[i]
+	explained := make([]float64, n)
+	for k, v := range vals {
+		if total > 0 {
+			explained[k] = v / total
 		}
-		if eigenvals[i] < minEigen && eigenvals[i] > 0 {
-			minEigen = eigenvals[i]
+	}
+
+	// A symbol's loading on a component is its eigenvector entry scaled
+	// by the component's standard deviation: its correlation with it.
+	k := e.components
+	if k > n {
+		k = n
+	}
+	loadings := make([][]float64, n)
+	for i := range loadings {
+		loadings[i] = make([]float64, k)
+		for c := 0; c < k; c++ {
+			loadings[i][c] = vecs[c][i] * math.Sqrt(math.Max(vals[c], 0))
+		}
+	}
+
+	var noiseBound float64
+	signals := 0
+	if e.rmt.Enabled {
+		_, noiseBound = m.MarchenkoPastur(n, e.observations())
+		for _, v := range vals {
+			if v > noiseBound {
+				signals++
+			}
 		}
 	}
 
+	// With RMT scaling the crisis level follows the noise band, so it
+	// means the same for 6 symbols as for 100.
+	crisisLevel := e.cfg.Eigenvalue
+	if e.rmt.Enabled && e.rmt.CrisisMultiple > 0 {
+		crisisLevel = e.rmt.CrisisMultiple * noiseBound
+	}
+
 	cond := maxEigen / minEigen
 	regime := "NORMAL"
 
-	if maxEigen > e.cfg.Eigenvalue {
+	if maxEigen > crisisLevel {
 		regime = "CRISIS"
 		e.addAlert(types.Alert{
 			Level:     "CRITICAL",
 			Symbol:    "MARKET",
 			Message:   "crisis mode detected",
 			Value:     maxEigen,
-			Threshold: e.cfg.Eigenvalue,
+			Threshold: crisisLevel,
 			Time:      time.Now(),
 		})
 	} else if cond > 50 {
 		regime = "STRESSED"
 	}
 
+	// The absorption ratio is defined on the covariance, where volatile
+	// symbols carry more weight; the other measures are scale-free.
+	covVals, _, ok := m.EigenSym(matrix.Cov)
+	if !ok {
+		log.Printf("eigen factorization failed")
+		return
+	}
+	ratio, shift := e.absorption.update(covVals, time.Now())
+	entropy, effRank := m.SpectralEntropy(vals)
+	e.systemicAlerts(ratio, shift, entropy)
+
+	var cleaned [][]float64
+	if e.rmt.Enabled && e.rmt.Clean {
+		cleaned = m.ClipEigen(vals, vecs, noiseBound)
+	}
+
 	e.mu.Lock()
 	e.mode = &types.Mode{
-		Eigenvalues: eigenvals,
-		MaxEigen:    maxEigen,
-		Condition:   cond,
-		Regime:      regime,
-		Time:        time.Now(),
+		Eigenvalues:  vals,
+		Eigenvectors: vecs,
+		Explained:    explained,
+		Loadings:     loadings,
+		MaxEigen:     maxEigen,
+		Condition:    cond,
+		Regime:       regime,
+		NoiseBound:   noiseBound,
+		Signals:      signals,
+
+		AbsorptionRatio: ratio,
+		AbsorptionShift: shift,
+		Entropy:         entropy,
+		EffectiveRank:   effRank,
+
+		Time: time.Now(),
+	}
+	if cleaned != nil && e.matrix == matrix {
+		withClean := *matrix
+		withClean.Cleaned = cleaned
+		e.matrix = &withClean
 	}
 	e.mu.Unlock()
 }
 
+// observations is the number of returns behind the current estimate:
+// the shortest window's returns, or for EWMA the effective sample size
+// (1+λ)/(1-λ) when that is smaller.
+func (e *Engine) observations() int {
+	t := e.winSize
+	for _, w := range e.windows {
+		if c := w.Count(); c < uint64(t) {
+			t = int(c)
+		}
+	}
+	t--
+
+	if e.ewma != nil {
+		if eff := int((1 + e.ewma.lambda) / (1 - e.ewma.lambda)); eff < t {
+			t = eff
+		}
+	}
+	return t
+}
+
+// Raise records an alert that originates outside the engine, such as a
+// feed failover.
+func (e *Engine) Raise(a types.Alert) {
+	e.addAlert(a)
+}
+
 func (e *Engine) addAlert(a types.Alert) {
 	e.mu.Lock()
 	e.alerts = append(e.alerts, a)
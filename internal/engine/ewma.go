@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"math"
+	"sync"
+
+	"matrixpulse/internal/config"
+)
+
+// ewma is a RiskMetrics-style exponentially weighted covariance:
+// each new aligned row of returns r updates Σ ← λΣ + (1-λ)·r·rᵀ, with
+// returns taken as zero mean. Rows come from the engine's rowBuilder as
+// prices arrive, so a faster symbol's returns fold into the next row
+// instead of being dropped. The estimate is seeded with the sample
+// covariance of the first warmup rows; until then there is none.
+type ewma struct {
+	mu     sync.Mutex
+	lambda float64
+	warmup int
+	seed   [][]float64 // rows held until the seed is taken
+	cov    [][]float64
+}
+
+func newEWMA(cfg config.EWMA) *ewma {
+	lambda := cfg.Lambda
+	if cfg.HalfLife > 0 {
+		lambda = math.Pow(0.5, 1/cfg.HalfLife)
+	}
+	return &ewma{
+		lambda: lambda,
+		warmup: cfg.Warmup,
+	}
+}
+
+// push applies one row of returns to the estimate, or holds it towards
+// the seed.
+func (w *ewma) push(row []float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cov == nil {
+		w.seed = append(w.seed, append([]float64(nil), row...))
+		if len(w.seed) >= w.warmup {
+			w.cov = seedCov(w.seed)
+			w.seed = nil
+		}
+		return
+	}
+
+	for i := range row {
+		for j := i; j < len(row); j++ {
+			c := w.lambda*w.cov[i][j] + (1-w.lambda)*row[i]*row[j]
+			w.cov[i][j] = c
+			w.cov[j][i] = c
+		}
+	}
+}
+
+// estimate returns a copy of the current estimate, or nil while it is
+// still unseeded.
+func (w *ewma) estimate() [][]float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cov == nil {
+		return nil
+	}
+	return copySquare(w.cov)
+}
+
+// seedCov is the sample covariance of rows, one row per observation.
+func seedCov(rows [][]float64) [][]float64 {
+	n, t := len(rows[0]), float64(len(rows))
+	means := make([]float64, n)
+	for _, row := range rows {
+		for i, x := range row {
+			means[i] += x / t
+		}
+	}
+
+	cov := newSquare(n)
+	for _, row := range rows {
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				cov[i][j] += (row[i] - means[i]) * (row[j] - means[j]) / (t - 1)
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			cov[i][j] = cov[j][i]
+		}
+	}
+	return cov
+}
+
+func copySquare(src [][]float64) [][]float64 {
+	out := newSquare(len(src))
+	for i := range src {
+		copy(out[i], src[i])
+	}
+	return out
+}
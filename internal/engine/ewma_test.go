@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+// TestEWMARecursion replays a short asynchronous feed and checks the
+// estimate against the recursion worked through by hand. A ticks twice
+// in some rounds; its in-between price must count towards the row's
+// return, not be dropped.
+func TestEWMARecursion(t *testing.T) {
+	const lambda = 0.9
+	e := New([]string{"A", "B"}, 5, config.Alerts{}, config.Engine{
+		Estimator: "ewma",
+		EWMA:      config.EWMA{Lambda: lambda, Warmup: 3},
+	})
+	t0 := time.Unix(1700000000, 0)
+	k := 0
+	tick := func(sym string, price float64) {
+		k++
+		e.Ingest(types.Tick{Symbol: sym, Price: price, Time: t0.Add(time.Duration(k) * time.Second)})
+	}
+
+	// Each round closes a row once both symbols have a new price.
+	rounds := [][]struct {
+		sym   string
+		price float64
+	}{
+		{{"A", 100}, {"B", 50}},
+		{{"A", 101}, {"A", 102}, {"B", 51}},
+		{{"B", 50}, {"A", 100}},
+		{{"A", 103}, {"B", 52}},
+		{{"A", 104}, {"A", 99}, {"A", 101}, {"B", 52}},
+		{{"A", 105}, {"B", 50}},
+	}
+	closes := [][2]float64{{100, 50}, {102, 51}, {100, 50}, {103, 52}, {101, 52}, {105, 50}}
+
+	var want [][]float64
+	for r, round := range rounds {
+		for _, tk := range round {
+			tick(tk.sym, tk.price)
+		}
+		e.Compute()
+
+		switch {
+		case r < 3:
+			// Three returns are needed for the seed: none before round 3.
+			if mat := e.Matrix(); mat != nil {
+				t.Fatalf("round %d: matrix published before warm-up: %v", r, mat.Cov)
+			}
+			continue
+		case r == 3:
+			// Seed: sample covariance of the first three returns.
+			var ret [3][2]float64
+			for s := 0; s < 3; s++ {
+				for i := 0; i < 2; i++ {
+					ret[s][i] = math.Log(closes[s+1][i] / closes[s][i])
+				}
+			}
+			want = newSquare(2)
+			for i := 0; i < 2; i++ {
+				for j := 0; j < 2; j++ {
+					mi := (ret[0][i] + ret[1][i] + ret[2][i]) / 3
+					mj := (ret[0][j] + ret[1][j] + ret[2][j]) / 3
+					for s := 0; s < 3; s++ {
+						want[i][j] += (ret[s][i] - mi) * (ret[s][j] - mj) / 2
+					}
+				}
+			}
+		default:
+			// Σ ← λΣ + (1-λ)·r·rᵀ on the return between closes.
+			a := math.Log(closes[r][0] / closes[r-1][0])
+			b := math.Log(closes[r][1] / closes[r-1][1])
+			want = [][]float64{
+				{lambda*want[0][0] + (1-lambda)*a*a, lambda*want[0][1] + (1-lambda)*a*b},
+				{lambda*want[1][0] + (1-lambda)*b*a, lambda*want[1][1] + (1-lambda)*b*b},
+			}
+		}
+		covClose(t, "round", r, e.Matrix().Cov, want)
+	}
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"sync"
+	"time"
+
+	m "matrixpulse/internal/math"
+)
+
+// tickHistory holds every symbol's ticks over the last span of tick time
+// for the Hayashi–Yoshida estimator. Tick-count windows cover very
+// different stretches for fast and slow names, often not overlapping at
+// all; a common span of time keeps enough of the slow name's intervals
+// to pair with the fast one's. Each symbol also keeps its newest tick
+// from before the span, so the interval straddling its start survives.
+type tickHistory struct {
+	mu     sync.Mutex
+	span   time.Duration
+	index  map[string]int
+	prices [][]float64
+	times  [][]time.Time
+	latest time.Time
+}
+
+func newTickHistory(symbols []string, span time.Duration) *tickHistory {
+	h := &tickHistory{
+		span:   span,
+		index:  make(map[string]int, len(symbols)),
+		prices: make([][]float64, len(symbols)),
+		times:  make([][]time.Time, len(symbols)),
+	}
+	for i, sym := range symbols {
+		h.index[sym] = i
+	}
+	return h
+}
+
+// push records a tick. Ticks stamped before their symbol's previous one
+// are dropped, since the estimator needs ascending times.
+func (h *tickHistory) push(sym string, price float64, at time.Time) {
+	i, ok := h.index[sym]
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ts := h.times[i]; len(ts) > 0 && at.Before(ts[len(ts)-1]) {
+		return
+	}
+	h.prices[i] = append(h.prices[i], price)
+	h.times[i] = append(h.times[i], at)
+	if at.After(h.latest) {
+		h.latest = at
+	}
+	h.trim(i)
+}
+
+// trim drops symbol i's ticks older than the span, keeping the newest
+// one at or before its start.
+func (h *tickHistory) trim(i int) {
+	cutoff := h.latest.Add(-h.span)
+	ts := h.times[i]
+	k := 0
+	for k+1 < len(ts) && !ts[k+1].After(cutoff) {
+		k++
+	}
+	h.prices[i] = h.prices[i][k:]
+	h.times[i] = ts[k:]
+}
+
+// snapshot copies out every symbol's ticks, oldest first.
+func (h *tickHistory) snapshot() ([][]float64, [][]time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prices := make([][]float64, len(h.prices))
+	times := make([][]time.Time, len(h.times))
+	for i := range h.prices {
+		// A symbol that has stopped ticking is only trimmed here.
+		h.trim(i)
+		prices[i] = append([]float64(nil), h.prices[i]...)
+		times[i] = append([]time.Time(nil), h.times[i]...)
+	}
+	return prices, times
+}
+
+// hayashiYoshidaCov estimates covariance straight from each symbol's
+// tick times over the configured span of tick time. The histories are
+// first cut to the span all of them cover; the diagonal is each symbol's
+// realized variance over that span and the off-diagonal entries are
+// Hayashi–Yoshida sums, so entries are totals over the span rather than
+// per-return figures. It returns nil until every symbol has two ticks
+// and the histories overlap.
+func (e *Engine) hayashiYoshidaCov() [][]float64 {
+	prices, times := e.hy.snapshot()
+	for i := range prices {
+		if len(prices[i]) < 2 {
+			return nil
+		}
+	}
+
+	prices, times, ok := m.CommonSpan(prices, times)
+	if !ok {
+		return nil
+	}
+
+	n := len(e.symbols)
+	cov := newSquare(n)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			c := m.HayashiYoshida(prices[i], times[i], prices[j], times[j])
+			cov[i][j] = c
+			cov[j][i] = c
+		}
+	}
+	return cov
+}
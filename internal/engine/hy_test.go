@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+// TestHayashiYoshidaFastSlow observes two log prices with correlation
+// 0.6, one every 25 ms and one every 10 s. With window_size 120 the fast
+// name's last 120 ticks span 3 s, usually after the slow name's last
+// tick; the history by time must still pair them and recover the
+// correlation.
+func TestHayashiYoshidaFastSlow(t *testing.T) {
+	const (
+		rho   = 0.6
+		step  = 25 * time.Millisecond
+		every = 400 // fast steps per slow tick
+		steps = 48000
+	)
+	e := New([]string{"FAST", "SLOW"}, 120, config.Alerts{}, config.Engine{
+		Estimator:      "hayashi_yoshida",
+		HayashiYoshida: config.HayashiYoshida{WindowSec: 600},
+	})
+
+	rng := rand.New(rand.NewSource(5))
+	t0 := time.Unix(1700000000, 0)
+	fast, slow := 100.0, 100.0
+	for k := 0; k < steps; k++ {
+		z1 := rng.NormFloat64()
+		z2 := rho*z1 + math.Sqrt(1-rho*rho)*rng.NormFloat64()
+		fast *= math.Exp(0.001 * z1)
+		slow *= math.Exp(0.001 * z2)
+
+		at := t0.Add(time.Duration(k) * step)
+		e.Ingest(types.Tick{Symbol: "FAST", Price: fast, Time: at})
+		// Offset the slow ticks so they never share a timestamp with
+		// the end of the fast window.
+		if k%every == every/3 {
+			e.Ingest(types.Tick{Symbol: "SLOW", Price: slow, Time: at})
+		}
+	}
+	e.Compute()
+
+	mat := e.Matrix()
+	if mat == nil {
+		t.Fatal("no matrix published")
+	}
+	if r := mat.Cor[0][1]; math.Abs(r-rho) > 0.2 {
+		t.Errorf("correlation = %.3f, want %.1f ± 0.2", r, rho)
+	}
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	m "matrixpulse/internal/math"
+	"matrixpulse/internal/types"
+)
+
+// leadLagState follows one pair's peak lag across updates.
+type leadLagState struct {
+	lag     int
+	stable  int
+	alerted bool
+}
+
+// computeLeadLag correlates each pair's aligned returns at every lag from
+// -MaxLag to +MaxLag samples and keeps the lag with the largest absolute
+// correlation. A positive lag ℓ for the pair (i, j) means returns of i
+// line up with returns of j ℓ samples later: i leads. When a pair keeps
+// the same non-zero peak lag, at or above the lead–lag threshold, for
+// StableCycles updates in a row, one alert is raised; it re-arms when the
+// relationship breaks. Only updates that find new prices in the windows
+// count: recomputing unchanged windows proves nothing about stability.
+func (e *Engine) computeLeadLag() {
+	var seq uint64
+	for _, w := range e.windows {
+		seq += w.Count()
+	}
+	if seq == e.leadLagSeq {
+		return
+	}
+	e.leadLagSeq = seq
+
+	returns := e.alignedReturns()
+	if returns == nil {
+		return
+	}
+	n := len(returns)
+	maxLag := e.leadLag.MaxLag
+	if limit := len(returns[0]) - 2; maxLag > limit {
+		maxLag = limit
+	}
+	if maxLag < 1 {
+		return
+	}
+
+	now := time.Now()
+	pairs := make([]types.LeadLag, 0, n*(n-1)/2)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			zero := m.Pearson(returns[i], returns[j])
+			lag, peak := 0, zero
+			for l := 1; l <= maxLag; l++ {
+				if r := laggedCor(returns[i], returns[j], l); math.Abs(r) > math.Abs(peak) {
+					lag, peak = l, r
+				}
+				if r := laggedCor(returns[j], returns[i], l); math.Abs(r) > math.Abs(peak) {
+					lag, peak = -l, r
+				}
+			}
+
+			ll := types.LeadLag{
+				Leader:   e.symbols[i],
+				Follower: e.symbols[j],
+				Lag:      lag,
+				Cor:      peak,
+				ZeroLag:  zero,
+			}
+			if lag < 0 {
+				ll.Leader, ll.Follower, ll.Lag = e.symbols[j], e.symbols[i], -lag
+			}
+			ll.Stable = e.trackLeadLag(i*n+j, lag, ll, now)
+			pairs = append(pairs, ll)
+		}
+	}
+
+	e.mu.Lock()
+	e.leadLags = pairs
+	e.mu.Unlock()
+}
+
+// trackLeadLag updates the pair's stability count and alerts when it
+// first reaches StableCycles. lag is the peak lag signed relative to the
+// pair's symbol order, so a reversal of who leads breaks the streak. It
+// returns the count.
+func (e *Engine) trackLeadLag(key, lag int, ll types.LeadLag, now time.Time) int {
+	st := e.leadLagStates[key]
+	if st == nil {
+		st = &leadLagState{}
+		e.leadLagStates[key] = st
+	}
+
+	t := e.cfg.LeadLag
+	if lag == 0 || t <= 0 || math.Abs(ll.Cor) < t {
+		*st = leadLagState{}
+		return 0
+	}
+	if lag != st.lag {
+		*st = leadLagState{lag: lag}
+	}
+
+	st.stable++
+	if st.stable >= e.leadLag.StableCycles && !st.alerted {
+		st.alerted = true
+		e.addAlert(types.Alert{
+			Level:     "INFO",
+			Symbol:    ll.Leader + "-" + ll.Follower,
+			Message:   fmt.Sprintf("%s leads %s by %d", ll.Leader, ll.Follower, ll.Lag),
+			Value:     ll.Cor,
+			Threshold: t,
+			Time:      now,
+		})
+	}
+	return st.stable
+}
+
+// laggedCor correlates x with y shifted lag samples later.
+func laggedCor(x, y []float64, lag int) float64 {
+	return m.Pearson(x[:len(x)-lag], y[lag:])
+}
+
+// LeadLag returns every pair's peak lagged correlation from the last
+// Compute, or nil when lead–lag analysis is off.
+func (e *Engine) LeadLag() []types.LeadLag {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]types.LeadLag(nil), e.leadLags...)
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+// TestLeadLag feeds B the returns of A two samples late and checks the
+// lead is found whichever way round the symbols are listed, that its
+// alert fires once after StableCycles updates, and that it re-arms once
+// the relationship breaks.
+func TestLeadLag(t *testing.T) {
+	for _, syms := range [][]string{{"A", "B"}, {"B", "A"}} {
+		e := New(syms, 60, config.Alerts{LeadLag: 0.5}, config.Engine{
+			LeadLag: config.LeadLag{Enabled: true, MaxLag: 5, StableCycles: 3},
+		})
+		rng := rand.New(rand.NewSource(31))
+		t0 := time.Unix(1700000000, 0)
+		price := map[string]float64{"A": 100, "B": 100}
+		var past []float64 // A's returns, newest last
+		k := 0
+		step := func(linked bool) {
+			a := 0.01 * rng.NormFloat64()
+			b := 0.01 * rng.NormFloat64()
+			if linked && len(past) >= 2 {
+				b = past[len(past)-2] + 0.001*rng.NormFloat64()
+			}
+			past = append(past, a)
+			price["A"] *= math.Exp(a)
+			price["B"] *= math.Exp(b)
+			k++
+			for _, sym := range syms {
+				e.Ingest(types.Tick{Symbol: sym, Price: price[sym], Time: t0.Add(time.Duration(k) * time.Second)})
+			}
+		}
+		alerts := func() int {
+			count := 0
+			for _, a := range e.Alerts() {
+				if strings.Contains(a.Message, "leads") {
+					count++
+				}
+			}
+			return count
+		}
+
+		for r := 0; r < 60; r++ {
+			step(true)
+		}
+		for c := 1; c <= 5; c++ {
+			step(true)
+			e.computeLeadLag()
+			ll := e.LeadLag()
+			if len(ll) != 1 || ll[0].Leader != "A" || ll[0].Follower != "B" || ll[0].Lag != 2 || ll[0].Cor < 0.9 {
+				t.Fatalf("%v update %d: lead-lag %+v, want A leading B by 2", syms, c, ll)
+			}
+			if ll[0].Stable != c {
+				t.Errorf("%v update %d: Stable = %d", syms, c, ll[0].Stable)
+			}
+			want := 0
+			if c >= 3 {
+				want = 1
+			}
+			if got := alerts(); got != want {
+				t.Errorf("%v update %d: %d lead alerts, want %d", syms, c, got, want)
+			}
+		}
+
+		// A window of unrelated returns breaks the lead and re-arms it.
+		for r := 0; r < 60; r++ {
+			step(false)
+		}
+		e.computeLeadLag()
+		if ll := e.LeadLag(); ll[0].Stable != 0 {
+			t.Errorf("%v: lead-lag %+v after the link broke, want Stable 0", syms, ll)
+		}
+		for r := 0; r < 60; r++ {
+			step(true)
+		}
+		for c := 0; c < 3; c++ {
+			step(true)
+			e.computeLeadLag()
+		}
+		if got := alerts(); got != 2 {
+			t.Errorf("%v: %d lead alerts after the lead returned, want 2", syms, got)
+		}
+	}
+}
+
+// TestLeadLagNeedsNewData computes many times over the same windows, as
+// a high update_hz does between grid intervals. A strong lead must not
+// count as stable from recomputing identical returns.
+func TestLeadLagNeedsNewData(t *testing.T) {
+	e := New([]string{"A", "B"}, 60, config.Alerts{LeadLag: 0.5}, config.Engine{
+		LeadLag: config.LeadLag{Enabled: true, MaxLag: 5, StableCycles: 3},
+	})
+	rng := rand.New(rand.NewSource(37))
+	t0 := time.Unix(1700000000, 0)
+	a, b := 100.0, 100.0
+	var past []float64
+	for k := 0; k < 60; k++ {
+		r := 0.01 * rng.NormFloat64()
+		s := 0.01 * rng.NormFloat64()
+		if len(past) >= 2 {
+			s = past[len(past)-2]
+		}
+		past = append(past, r)
+		a *= math.Exp(r)
+		b *= math.Exp(s)
+		e.Ingest(types.Tick{Symbol: "A", Price: a, Time: t0.Add(time.Duration(k) * time.Second)})
+		e.Ingest(types.Tick{Symbol: "B", Price: b, Time: t0.Add(time.Duration(k) * time.Second)})
+	}
+
+	for c := 0; c < 40; c++ {
+		e.Compute()
+	}
+	ll := e.LeadLag()
+	if len(ll) != 1 || ll[0].Lag != 2 || ll[0].Stable != 1 {
+		t.Errorf("lead-lag %+v, want A leading B by 2 with Stable 1", ll)
+	}
+	for _, al := range e.Alerts() {
+		if strings.Contains(al.Message, "leads") {
+			t.Errorf("alert %q from recomputing unchanged windows", al.Message)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"time"
+
+	m "matrixpulse/internal/math"
+	"matrixpulse/internal/network"
+	"matrixpulse/internal/types"
+	"matrixpulse/internal/window"
+)
+
+// computeNetwork builds the correlation network and scores each symbol
+// on it. The mean tree length and each symbol's betweenness and degree
+// are kept over the last History updates and reported as z-scores, so a
+// symbol turning into a hub stands out. An alert is raised once when the
+// length falls below minus the tree length threshold: the tree
+// contracting is the classic sign of a market moving as one.
+func (e *Engine) computeNetwork(cor [][]float64) {
+	n := len(cor)
+	tree := network.MST(cor)
+	thresh := network.Threshold(cor, e.netCfg.Threshold)
+
+	degree := network.Degree(tree, n)
+	threshDegree := network.Degree(thresh, n)
+	between := network.Betweenness(tree, n)
+	eigen := network.Eigenvector(tree, n)
+
+	nodes := make([]types.NodeStats, n)
+	hub := 0
+	for i := range nodes {
+		nodes[i] = types.NodeStats{
+			Symbol:          e.symbols[i],
+			Degree:          degree[i],
+			ThresholdDegree: threshDegree[i],
+			Betweenness:     between[i],
+			Eigenvector:     eigen[i],
+			BetweennessZ:    zScore(e.betweenHist[i], between[i]),
+			DegreeZ:         zScore(e.degreeHist[i], float64(degree[i])),
+		}
+		if between[i] > between[hub] {
+			hub = i
+		}
+	}
+
+	length := network.Length(tree)
+	mean := 0.0
+	if len(tree) > 0 {
+		mean = length / float64(len(tree))
+	}
+
+	z := zScore(e.treeLengths, mean)
+
+	now := time.Now()
+	if t := e.cfg.TreeLength; t > 0 {
+		low := z < -t
+		if low && !e.treeShort {
+			e.addAlert(types.Alert{
+				Level:     "HIGH",
+				Symbol:    "MARKET",
+				Message:   "spanning tree contracting",
+				Value:     z,
+				Threshold: -t,
+				Time:      now,
+			})
+		}
+		e.treeShort = low
+	}
+
+	e.mu.Lock()
+	e.network = &types.Network{
+		Tree:       tree,
+		Threshold:  thresh,
+		Length:     length,
+		MeanLength: mean,
+		LengthZ:    z,
+		Nodes:      nodes,
+		Hub:        e.symbols[hub],
+		Time:       now,
+	}
+	e.mu.Unlock()
+}
+
+// zScore adds v to hist and returns how many standard deviations it lies
+// from the history's mean, or 0 while the history is flat.
+func zScore(hist *window.Rolling, v float64) float64 {
+	hist.Push(v)
+	values := hist.Snapshot()
+	if len(values) < 2 {
+		return 0
+	}
+	mu := m.Mean(values)
+	if sd := m.StdDev(values, mu); sd > 0 {
+		return (v - mu) / sd
+	}
+	return 0
+}
+
+// Network returns the correlation network from the last Compute, or nil
+// when network analysis is off.
+func (e *Engine) Network() *types.Network {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.network
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"testing"
+
+	"matrixpulse/internal/config"
+)
+
+// linked returns a correlation matrix with 0.9 on the given pairs and
+// 0.1 elsewhere, so the pairs form the minimum spanning tree.
+func linked(n int, pairs [][2]int) [][]float64 {
+	cor := make([][]float64, n)
+	for i := range cor {
+		cor[i] = make([]float64, n)
+		for j := range cor[i] {
+			cor[i][j] = 0.1
+		}
+		cor[i][i] = 1
+	}
+	for _, p := range pairs {
+		cor[p[0]][p[1]], cor[p[1]][p[0]] = 0.9, 0.9
+	}
+	return cor
+}
+
+// TestCentralityHistory checks that a symbol turning from the end of a
+// chain into the hub of a star stands out against its own history.
+func TestCentralityHistory(t *testing.T) {
+	syms := []string{"A", "B", "C", "D", "E"}
+	e := New(syms, 10, config.Alerts{}, config.Engine{Network: config.Network{Enabled: true, History: 50}})
+
+	chain := linked(5, [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}})
+	for k := 0; k < 30; k++ {
+		e.computeNetwork(chain)
+	}
+	for _, node := range e.Network().Nodes {
+		if node.BetweennessZ != 0 || node.DegreeZ != 0 {
+			t.Fatalf("%s: z = %v, %v under an unchanged tree, want 0", node.Symbol, node.BetweennessZ, node.DegreeZ)
+		}
+	}
+
+	// A has been a leaf for 30 updates; one outlier after 30 equal values
+	// lies 30/√31 ≈ 5.4 standard deviations out.
+	e.computeNetwork(linked(5, [][2]int{{0, 1}, {0, 2}, {0, 3}, {0, 4}}))
+	net := e.Network()
+	a := net.Nodes[0]
+	if net.Hub != "A" || a.Betweenness != 1 || a.Degree != 4 {
+		t.Fatalf("star: hub %s, A = %+v", net.Hub, a)
+	}
+	if a.BetweennessZ < 5 || a.DegreeZ < 5 {
+		t.Errorf("A: BetweennessZ %v, DegreeZ %v, want > 5", a.BetweennessZ, a.DegreeZ)
+	}
+	// C was the middle of the chain and is now a leaf.
+	if c := net.Nodes[2]; c.BetweennessZ > -5 || c.DegreeZ > -5 {
+		t.Errorf("C: BetweennessZ %v, DegreeZ %v, want < -5", c.BetweennessZ, c.DegreeZ)
+	}
+}
@@ -0,0 +1,105 @@
+package engine
+
+import "sync"
+
+// onlineCov keeps running sums and co-moments over the most recent
+// aligned rows of returns from the engine's rowBuilder, so the sample
+// covariance costs O(n²) per row and per Compute however long the window
+// is. Rows are added as they arrive and subtracted as they fall out;
+// once per window length the sums are rebuilt from the stored rows so
+// rounding error cannot build up.
+type onlineCov struct {
+	mu    sync.Mutex
+	rows  [][]float64 // ring of return rows
+	head  int         // index of the oldest row
+	count int
+
+	sum   []float64
+	cross [][]float64
+
+	pending int // rows pushed since the last rebuild
+}
+
+func newOnlineCov(n, winSize int) *onlineCov {
+	size := winSize - 1
+	if size < 1 {
+		size = 1
+	}
+	rows := make([][]float64, size)
+	for i := range rows {
+		rows[i] = make([]float64, n)
+	}
+	return &onlineCov{
+		rows:  rows,
+		sum:   make([]float64, n),
+		cross: newSquare(n),
+	}
+}
+
+// push adds one row of returns, evicting the oldest when full.
+func (o *onlineCov) push(row []float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.count == len(o.rows) {
+		o.apply(o.rows[o.head], -1)
+		copy(o.rows[o.head], row)
+		o.head = (o.head + 1) % len(o.rows)
+	} else {
+		copy(o.rows[(o.head+o.count)%len(o.rows)], row)
+		o.count++
+	}
+	o.apply(row, 1)
+
+	o.pending++
+	if o.pending >= len(o.rows) {
+		o.rebuild()
+	}
+}
+
+func (o *onlineCov) apply(row []float64, sign float64) {
+	for i, x := range row {
+		o.sum[i] += sign * x
+		for j := i; j < len(row); j++ {
+			o.cross[i][j] += sign * x * row[j]
+		}
+	}
+}
+
+func (o *onlineCov) rebuild() {
+	for i := range o.sum {
+		o.sum[i] = 0
+		for j := range o.cross[i] {
+			o.cross[i][j] = 0
+		}
+	}
+	for k := 0; k < o.count; k++ {
+		o.apply(o.rows[(o.head+k)%len(o.rows)], 1)
+	}
+	o.pending = 0
+}
+
+// cov is the sample covariance of the rows held, matching
+// math.Covariance on the same returns, or nil before the first row.
+func (o *onlineCov) cov() [][]float64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.count == 0 {
+		return nil
+	}
+	n := len(o.sum)
+	out := newSquare(n)
+	if o.count < 2 {
+		return out
+	}
+	m := float64(o.count)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			c := (o.cross[i][j] - o.sum[i]*o.sum[j]/m) / (m - 1)
+			out[i][j] = c
+			out[j][i] = c
+		}
+	}
+	return out
+}
@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+// covClose compares two covariance matrices to rounding.
+func covClose(t *testing.T, stage string, k int, got, want [][]float64) {
+	t.Helper()
+	if (got == nil) != (want == nil) {
+		t.Fatalf("%s %d: online nil = %v, reference nil = %v", stage, k, got == nil, want == nil)
+	}
+	for i := range want {
+		for j := range want {
+			if d := math.Abs(got[i][j] - want[i][j]); d > 1e-12*(1+math.Abs(want[i][j])) {
+				t.Fatalf("%s %d: cov[%d][%d] = %g, want %g", stage, k, i, j, got[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+// TestOnlineMatchesSample checks the running sums against the full
+// recompute when symbols advance together: in lockstep tick mode, with
+// batches of varying size including some longer than the window, and in
+// grid mode over asynchronous ticks.
+func TestOnlineMatchesSample(t *testing.T) {
+	syms := []string{"A", "B", "C"}
+	rng := rand.New(rand.NewSource(3))
+	t0 := time.Unix(1700000000, 0)
+
+	e := New(syms, 40, config.Alerts{}, config.Engine{Incremental: true})
+	prices := map[string]float64{"A": 100, "B": 50, "C": 20}
+	step := 0
+	for k := 0; k < 60; k++ {
+		for r := 0; r <= k%7*8; r++ {
+			for _, sym := range syms {
+				prices[sym] *= math.Exp(0.01 * rng.NormFloat64())
+				e.Ingest(types.Tick{Symbol: sym, Price: prices[sym], Time: t0.Add(time.Duration(step) * time.Second)})
+			}
+			step++
+		}
+		covClose(t, "lockstep", k, e.online.cov(), e.sampleCov())
+	}
+
+	// Grid mode: A ticks about every 100 ms, B and C far less often and
+	// at random; the sampler turns them into aligned rows.
+	e = New(syms, 40, config.Alerts{}, config.Engine{
+		Incremental: true,
+		Sampling:    config.Sampling{Mode: "grid", IntervalMs: 1000},
+	})
+	rate := map[string]float64{"A": 0.9, "B": 0.1, "C": 0.03}
+	at := t0
+	for k := 0; k < 2000; k++ {
+		at = at.Add(100 * time.Millisecond)
+		for _, sym := range syms {
+			if rng.Float64() < rate[sym] {
+				prices[sym] *= math.Exp(0.01 * rng.NormFloat64())
+				e.Ingest(types.Tick{Symbol: sym, Price: prices[sym], Time: at})
+			}
+		}
+		if k%7 == 0 {
+			covClose(t, "grid", k, e.online.cov(), e.sampleCov())
+		}
+	}
+}
+
+// TestOnlineMatchesFullPipeline runs an incremental and a full-recompute
+// engine side by side on asynchronous ticks under grid sampling, with
+// shrinkage and RMT on. Everything they publish from the windows must
+// describe the same sample as the running sums: covariance, shrinkage
+// intensity and noise band alike.
+func TestOnlineMatchesFullPipeline(t *testing.T) {
+	syms := []string{"A", "B", "C", "D"}
+	opts := config.Engine{
+		Sampling:  config.Sampling{Mode: "grid", IntervalMs: 1000},
+		Shrinkage: "ledoit_wolf",
+		RMT:       config.RMT{Enabled: true},
+	}
+	full := New(syms, 60, config.Alerts{}, opts)
+	opts.Incremental = true
+	inc := New(syms, 60, config.Alerts{}, opts)
+
+	rng := rand.New(rand.NewSource(8))
+	prices := []float64{100, 50, 20, 10}
+	at := time.Unix(1700000000, 0)
+	for k := 0; k < 3000; k++ {
+		at = at.Add(150 * time.Millisecond)
+		i := rng.Intn(len(syms))
+		prices[i] *= math.Exp(0.01 * rng.NormFloat64())
+		tick := types.Tick{Symbol: syms[i], Price: prices[i], Time: at}
+		full.Ingest(tick)
+		inc.Ingest(tick)
+
+		if k%50 != 49 {
+			continue
+		}
+		full.Compute()
+		inc.Compute()
+		want, got := full.Matrix(), inc.Matrix()
+		if want == nil || got == nil {
+			continue
+		}
+		covClose(t, "pipeline", k, got.Cov, want.Cov)
+		if math.Abs(got.Shrinkage-want.Shrinkage) > 1e-12 {
+			t.Fatalf("pipeline %d: shrinkage %v, want %v", k, got.Shrinkage, want.Shrinkage)
+		}
+		if g, w := inc.Mode().NoiseBound, full.Mode().NoiseBound; g != w {
+			t.Fatalf("pipeline %d: noise bound %v, want %v", k, g, w)
+		}
+	}
+	if inc.Matrix() == nil {
+		t.Fatal("no matrix published")
+	}
+}
+
+// BenchmarkCompute compares a full covariance recompute with the running
+// co-moments on a large universe under grid sampling: each iteration
+// moves one grid interval on, adding one tick per symbol, and
+// recomputes. In the async case every other symbol ticks twice per
+// interval, as a feed with uneven tick rates would.
+func BenchmarkCompute(b *testing.B) {
+	const nSyms, winSize = 100, 10000
+
+	for _, bc := range []struct {
+		name        string
+		incremental bool
+		extra       int // every extra-th symbol ticks twice; 0 for none
+	}{
+		{"sample", false, 0},
+		{"incremental", true, 0},
+		{"incremental-async", true, 2},
+	} {
+		b.Run(fmt.Sprintf("%s/%dx%d", bc.name, nSyms, winSize), func(b *testing.B) {
+			syms := make([]string, nSyms)
+			prices := make([]float64, nSyms)
+			for i := range syms {
+				syms[i] = fmt.Sprintf("S%03d", i)
+				prices[i] = 100
+			}
+			e := New(syms, winSize, config.Alerts{Correlation: 0.82, Eigenvalue: 2.8}, config.Engine{
+				Incremental: bc.incremental,
+				Sampling:    config.Sampling{Mode: "grid", IntervalMs: 1000},
+			})
+
+			rng := rand.New(rand.NewSource(1))
+			t0 := time.Unix(1700000000, 0)
+			step := 0
+			advance := func() {
+				at := t0.Add(time.Duration(step) * time.Second)
+				for i, sym := range syms {
+					ticks := 1
+					if bc.extra > 0 && i%bc.extra == 0 {
+						ticks = 2
+					}
+					for r := 0; r < ticks; r++ {
+						prices[i] *= math.Exp(0.001 * rng.NormFloat64())
+						e.Ingest(types.Tick{Symbol: sym, Price: prices[i], Time: at})
+					}
+				}
+				step++
+			}
+			for k := 0; k < winSize; k++ {
+				advance()
+			}
+			e.Compute()
+
+			b.ResetTimer()
+			for k := 0; k < b.N; k++ {
+				advance()
+				e.Compute()
+			}
+		})
+	}
+}
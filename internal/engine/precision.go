@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"log"
+	"math"
+
+	m "matrixpulse/internal/math"
+)
+
+// precision returns the precision matrix for cov and the partial
+// correlations it implies, or nils when precision output is off or cov
+// cannot be inverted. The graphical lasso runs on the correlation matrix
+// so one lambda fits any mix of volatilities, and its result is scaled
+// back to covariance units. Each fit starts from the previous one.
+func (e *Engine) precision(cov [][]float64) (prec, partial [][]float64) {
+	switch e.precMode {
+	case "inverse":
+		inv, ok := m.Inverse(cov)
+		if !ok {
+			return nil, nil
+		}
+		return inv, m.PartialCorrelation(inv)
+
+	case "glasso":
+		for i := range cov {
+			if cov[i][i] <= 0 {
+				return nil, nil
+			}
+		}
+		corPrec, converged := e.glasso.Fit(pearsonFromCov(cov))
+		if !converged && !e.glassoWarned {
+			log.Printf("graphical lasso did not converge in %d iterations", e.glasso.MaxIter)
+			e.glassoWarned = true
+		}
+
+		prec = newSquare(len(cov))
+		for i := range cov {
+			for j := range cov {
+				prec[i][j] = corPrec[i][j] / math.Sqrt(cov[i][i]*cov[j][j])
+			}
+		}
+		return prec, m.PartialCorrelation(corPrec)
+
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"math"
+	"sync"
+)
+
+// rowBuilder turns the prices entering the windows into aligned rows of
+// log returns for the estimators that update incrementally. A row closes
+// once every symbol has a new price since the previous row and holds
+// each symbol's return to its latest price (refresh-time sampling).
+//
+// The grid sampler, and feeds that tick all symbols together, give every
+// symbol exactly one price per row, so the rows are the windows' own and
+// the estimates match a full recompute; config.Validate requires grid
+// sampling for the incremental sample estimator for that reason. With
+// asynchronous ticks, as EWMA sees them in tick mode, a faster symbol's
+// intermediate prices fold into its return to the next row rather than
+// shifting every row against the others, so each price costs O(1) and
+// each row O(n²) whatever the tick rates.
+type rowBuilder struct {
+	mu     sync.Mutex
+	index  map[string]int
+	last   []float64
+	prev   []float64
+	fresh  []bool
+	nFresh int
+	primed bool // prev holds a complete row
+	row    []float64
+	sinks  []func(row []float64)
+}
+
+func newRowBuilder(symbols []string) *rowBuilder {
+	b := &rowBuilder{
+		index: make(map[string]int, len(symbols)),
+		last:  make([]float64, len(symbols)),
+		prev:  make([]float64, len(symbols)),
+		fresh: make([]bool, len(symbols)),
+		row:   make([]float64, len(symbols)),
+	}
+	for i, sym := range symbols {
+		b.index[sym] = i
+	}
+	return b
+}
+
+// feed registers fn to receive every completed row of returns. The row
+// is reused, so fn must copy what it keeps.
+func (b *rowBuilder) feed(fn func(row []float64)) {
+	b.sinks = append(b.sinks, fn)
+}
+
+// observe records a price as it enters sym's window.
+func (b *rowBuilder) observe(sym string, price float64) {
+	i, ok := b.index[sym]
+	if !ok {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.last[i] = price
+	if !b.fresh[i] {
+		b.fresh[i] = true
+		b.nFresh++
+	}
+	if b.nFresh < len(b.fresh) {
+		return
+	}
+
+	if b.primed {
+		for k, p := range b.last {
+			b.row[k] = math.Log(p / b.prev[k])
+		}
+		for _, fn := range b.sinks {
+			fn(b.row)
+		}
+	}
+	copy(b.prev, b.last)
+	b.primed = true
+	for k := range b.fresh {
+		b.fresh[k] = false
+	}
+	b.nFresh = 0
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"matrixpulse/internal/types"
+)
+
+// sampler snaps asynchronous ticks onto a common time grid so every
+// window holds prices for the same instants. Ticks only update their
+// symbol's latest price; when a tick's timestamp passes one or more grid
+// boundaries, each boundary pushes every symbol's latest price as of that
+// boundary (last observation carried forward). Time is taken from tick
+// timestamps, so replayed sessions sample the same way live ones do.
+// Prices go out through push, which adds them to the windows and
+// whatever is fed alongside them, one symbol at a time in symbol order.
+//
+// A tick more than maxLeap intervals past the grid is held until a later
+// tick confirms that time has moved on. A lone tick stamped in the
+// future would otherwise fill the window with flat rows and then stall
+// the grid until real time caught up with it; a genuine gap, such as
+// the overnight break in a replay, only delays sampling by one tick.
+type sampler struct {
+	mu       sync.Mutex
+	interval time.Duration
+	symbols  []string
+	tracked  map[string]bool
+	maxFill  int
+	push     func(sym string, price float64, at time.Time)
+
+	last map[string]float64
+	next time.Time
+	held *types.Tick
+}
+
+// maxLeap is how many grid intervals one tick may move the grid before
+// it needs a second tick to confirm it.
+const maxLeap = 8
+
+func newSampler(interval time.Duration, symbols []string, winSize int, push func(string, float64, time.Time)) *sampler {
+	tracked := make(map[string]bool, len(symbols))
+	for _, sym := range symbols {
+		tracked[sym] = true
+	}
+	return &sampler{
+		interval: interval,
+		symbols:  symbols,
+		tracked:  tracked,
+		maxFill:  winSize,
+		push:     push,
+		last:     make(map[string]float64, len(symbols)),
+	}
+}
+
+func (s *sampler) observe(tick types.Tick) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.tracked[tick.Symbol] {
+		return
+	}
+
+	if !s.next.IsZero() && tick.Time.After(s.next.Add(maxLeap*s.interval)) {
+		if s.held == nil || tick.Time.Before(s.held.Time) {
+			// Keep the earlier of two unconfirmed leaps: it is the more
+			// plausible one.
+			s.held = &tick
+			return
+		}
+		held := *s.held
+		s.held = nil
+		s.advance(held)
+		s.advance(tick)
+		return
+	}
+	if s.held != nil {
+		log.Printf("sampler: dropped %s tick stamped %v, %v past the grid", s.held.Symbol,
+			s.held.Time.Format(time.RFC3339Nano), s.held.Time.Sub(s.next))
+		s.held = nil
+	}
+	s.advance(tick)
+}
+
+// advance moves the grid up to the tick's time and records its price.
+func (s *sampler) advance(tick types.Tick) {
+	if s.next.IsZero() {
+		s.next = tick.Time.Truncate(s.interval).Add(s.interval)
+	}
+
+	// Boundaries strictly before this tick close with the prices seen so
+	// far; a tick stamped exactly on a boundary belongs to it.
+	if tick.Time.After(s.next) {
+		steps := int((tick.Time.Sub(s.next)-1)/s.interval) + 1
+
+		if len(s.last) == len(s.symbols) {
+			// Rows past a full window are identical to the ones kept.
+			fill := steps
+			if fill > s.maxFill {
+				fill = s.maxFill
+			}
+			for k := steps - fill; k < steps; k++ {
+				at := s.next.Add(time.Duration(k) * s.interval)
+				for _, sym := range s.symbols {
+					s.push(sym, s.last[sym], at)
+				}
+			}
+		}
+		s.next = s.next.Add(time.Duration(steps) * s.interval)
+	}
+
+	s.last[tick.Symbol] = tick.Price
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+// TestSamplerGrid checks where boundaries fall and what they carry: each
+// boundary holds every symbol's last price at or before it, a tick on a
+// boundary belongs to it, and nothing is pushed until every symbol has
+// ticked.
+func TestSamplerGrid(t *testing.T) {
+	e := New([]string{"A", "B"}, 10, config.Alerts{}, config.Engine{
+		Sampling: config.Sampling{Mode: "grid", IntervalMs: 1000},
+	})
+	t0 := time.Unix(1700000000, 0)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	tick := func(sym string, price float64, ms int) {
+		e.Ingest(types.Tick{Symbol: sym, Price: price, Time: at(ms)})
+	}
+
+	tick("A", 100, 200)
+	tick("A", 101, 1500) // passes 1s with only A seen: nothing pushed
+	tick("B", 50, 1700)
+	tick("A", 102, 2000) // on the 2s boundary, so part of it
+	tick("B", 51, 2000)
+	tick("B", 52, 2400)  // closes 2s
+	tick("A", 103, 5300) // closes 3s, 4s and 5s with the prices held since 2.4s
+
+	for _, tc := range []struct {
+		sym    string
+		prices []float64
+	}{
+		{"A", []float64{102, 102, 102, 102}},
+		{"B", []float64{51, 52, 52, 52}},
+	} {
+		prices, times := e.windows[tc.sym].SnapshotTimes()
+		if !reflect.DeepEqual(prices, tc.prices) {
+			t.Errorf("%s prices = %v, want %v", tc.sym, prices, tc.prices)
+		}
+		want := []time.Time{at(2000), at(3000), at(4000), at(5000)}
+		if len(times) != len(want) {
+			t.Fatalf("%s: %d samples, want %d", tc.sym, len(times), len(want))
+		}
+		for k := range want {
+			if !times[k].Equal(want[k]) {
+				t.Errorf("%s sample %d at %v, want %v", tc.sym, k, times[k], want[k])
+			}
+		}
+	}
+}
+
+// TestSamplerLongGap checks that a gap longer than the window pushes no
+// more than a window of carried-forward rows.
+func TestSamplerLongGap(t *testing.T) {
+	e := New([]string{"A", "B"}, 3, config.Alerts{}, config.Engine{
+		Sampling: config.Sampling{Mode: "grid", IntervalMs: 1000},
+	})
+	t0 := time.Unix(1700000000, 0)
+	e.Ingest(types.Tick{Symbol: "A", Price: 100, Time: t0})
+	e.Ingest(types.Tick{Symbol: "B", Price: 50, Time: t0})
+	e.Ingest(types.Tick{Symbol: "A", Price: 101, Time: t0.Add(time.Hour)})
+	// The leap is only taken once a second tick confirms it.
+	if c := e.windows["A"].Count(); c != 0 {
+		t.Fatalf("%d samples pushed on one tick an hour ahead, want 0", c)
+	}
+	e.Ingest(types.Tick{Symbol: "B", Price: 51, Time: t0.Add(time.Hour)})
+
+	for _, sym := range []string{"A", "B"} {
+		if c := e.windows[sym].Count(); c != 3 {
+			t.Errorf("%s: %d samples pushed over an hour's gap, want 3", sym, c)
+		}
+		_, times := e.windows[sym].SnapshotTimes()
+		if last := t0.Add(time.Hour - time.Second); !times[len(times)-1].Equal(last) {
+			t.Errorf("%s: last sample at %v, want %v", sym, times[len(times)-1], last)
+		}
+	}
+}
+
+// TestSamplerFutureTick sends one tick stamped a day ahead in the middle
+// of a steady stream. It must neither flatten the window nor stall the
+// grid: the stream's own boundaries keep being sampled.
+func TestSamplerFutureTick(t *testing.T) {
+	e := New([]string{"A", "B"}, 20, config.Alerts{}, config.Engine{
+		Sampling: config.Sampling{Mode: "grid", IntervalMs: 1000},
+	})
+	t0 := time.Unix(1700000000, 0)
+	tick := func(sym string, price float64, at time.Time) {
+		e.Ingest(types.Tick{Symbol: sym, Price: price, Time: at})
+	}
+
+	for k := 0; k < 10; k++ {
+		at := t0.Add(time.Duration(k) * time.Second)
+		tick("A", 100+float64(k), at)
+		tick("B", 50+float64(k), at)
+	}
+	tick("A", 999, t0.Add(24*time.Hour))
+	for k := 10; k < 20; k++ {
+		at := t0.Add(time.Duration(k) * time.Second)
+		tick("A", 100+float64(k), at)
+		tick("B", 50+float64(k), at)
+	}
+
+	prices, times := e.windows["A"].SnapshotTimes()
+	if len(prices) != 18 {
+		t.Fatalf("%d samples, want 18", len(prices))
+	}
+	for k, p := range prices {
+		at := t0.Add(time.Duration(k+1) * time.Second)
+		if p != 101+float64(k) || !times[k].Equal(at) {
+			t.Fatalf("sample %d = %v at %v, want %v at %v", k, p, times[k], 101+float64(k), at)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	m "matrixpulse/internal/math"
+)
+
+// shrink pulls cov towards the configured target using the intensity
+// estimated from the aligned window returns, and returns the result with
+// that intensity.
+func (e *Engine) shrink(cov [][]float64) ([][]float64, float64) {
+	returns := e.alignedReturns()
+	if returns == nil {
+		return cov, 0
+	}
+
+	var delta float64
+	switch e.shrinkage {
+	case "ledoit_wolf":
+		delta = m.LedoitWolf(returns)
+	case "oas":
+		delta = m.OAS(returns)
+	case "constant_correlation":
+		delta = m.ConstantCorrelation(returns)
+	default:
+		return cov, 0
+	}
+	return e.shrinkTo(cov, delta), delta
+}
+
+// shrinkTo blends a covariance or correlation matrix with the configured
+// target at intensity delta. On a correlation matrix the identity target
+// is the identity and the constant correlation target keeps the unit
+// diagonal, so the result is again a correlation matrix.
+func (e *Engine) shrinkTo(a [][]float64, delta float64) [][]float64 {
+	if e.shrinkage == "constant_correlation" {
+		return m.ShrinkTo(a, m.ConstantCorrelationTarget(a), delta)
+	}
+	return m.ShrinkTo(a, m.IdentityTarget(a), delta)
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"math"
+	"time"
+
+	"matrixpulse/internal/config"
+	m "matrixpulse/internal/math"
+	"matrixpulse/internal/types"
+)
+
+// absorption tracks the absorption ratio over time so its recent level
+// can be compared with a longer baseline. Both windows are durations, so
+// the shift does not depend on how often Compute runs.
+type absorption struct {
+	fraction    float64
+	short, long time.Duration
+	first       time.Time // first update
+	times       []time.Time
+	ratios      []float64
+}
+
+func newAbsorption(cfg config.Absorption) *absorption {
+	return &absorption{
+		fraction: cfg.Fraction,
+		short:    time.Duration(cfg.ShortSec * float64(time.Second)),
+		long:     time.Duration(cfg.LongSec * float64(time.Second)),
+	}
+}
+
+// update records the absorption ratio of the covariance eigenvalues
+// vals at now, using the top fraction of them, and returns it with the
+// standardized shift: the mean over the short window less the mean over
+// the long one, in long-window standard deviations. The shift stays 0
+// until the history covers twice the short window.
+func (a *absorption) update(vals []float64, now time.Time) (ratio, shift float64) {
+	k := int(math.Ceil(a.fraction * float64(len(vals))))
+	if k < 1 {
+		k = 1
+	}
+	ratio = m.AbsorptionRatio(vals, k)
+
+	if a.first.IsZero() {
+		a.first = now
+	}
+	a.times = append(a.times, now)
+	a.ratios = append(a.ratios, ratio)
+	drop := 0
+	for drop < len(a.times) && !a.times[drop].After(now.Add(-a.long)) {
+		drop++
+	}
+	a.times, a.ratios = a.times[drop:], a.ratios[drop:]
+
+	if now.Sub(a.first) < 2*a.short {
+		return ratio, 0
+	}
+	recent := len(a.times)
+	for recent > 0 && a.times[recent-1].After(now.Add(-a.short)) {
+		recent--
+	}
+	longMean := m.Mean(a.ratios)
+	sd := m.StdDev(a.ratios, longMean)
+	if sd == 0 {
+		return ratio, 0
+	}
+	return ratio, (m.Mean(a.ratios[recent:]) - longMean) / sd
+}
+
+// systemicAlerts checks the spectral indicators against their
+// thresholds; a threshold of 0 turns its check off. Each alerts once per
+// crossing and re-arms when the indicator returns inside its threshold.
+func (e *Engine) systemicAlerts(ratio, shift, entropy float64) {
+	now := time.Now()
+	if t := e.cfg.AbsorptionRatio; t > 0 {
+		e.systemicAlert(&e.ratioHigh, ratio > t, "absorption ratio high", ratio, t, now)
+	}
+	if t := e.cfg.AbsorptionShift; t > 0 {
+		e.systemicAlert(&e.shiftHigh, shift > t, "absorption ratio shift", shift, t, now)
+	}
+	if t := e.cfg.Entropy; t > 0 {
+		e.systemicAlert(&e.entropyLow, entropy < t, "spectral entropy collapse", entropy, t, now)
+	}
+}
+
+// systemicAlert raises a market alert when breached turns true and
+// records the new state in *state.
+func (e *Engine) systemicAlert(state *bool, breached bool, msg string, value, threshold float64, now time.Time) {
+	if breached && !*state {
+		e.addAlert(types.Alert{
+			Level:     "HIGH",
+			Symbol:    "MARKET",
+			Message:   msg,
+			Value:     value,
+			Threshold: threshold,
+			Time:      now,
+		})
+	}
+	*state = breached
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/math/mathtest"
+	"matrixpulse/internal/types"
+)
+
+func TestNewZeroEngineConfig(t *testing.T) {
+	syms := []string{"A", "B", "C"}
+	e := New(syms, 50, config.Alerts{}, config.Engine{})
+
+	rng := rand.New(rand.NewSource(1))
+	t0 := time.Unix(1700000000, 0)
+	for k := 0; k < 60; k++ {
+		for _, sym := range syms {
+			e.Ingest(types.Tick{Symbol: sym, Price: 100 * math.Exp(0.01*rng.NormFloat64()), Time: t0.Add(time.Duration(k) * time.Second)})
+		}
+	}
+	e.Compute()
+
+	if e.Matrix() == nil || e.Mode() == nil {
+		t.Fatal("Compute published no matrix or mode")
+	}
+}
+
+func TestSystemicAlertsOncePerCrossing(t *testing.T) {
+	e := New([]string{"A", "B"}, 50, config.Alerts{AbsorptionRatio: 0.8, Entropy: 0.5}, config.Engine{})
+
+	for _, ratio := range []float64{0.9, 0.95, 0.9, 0.7, 0.85, 0.9} {
+		e.systemicAlerts(ratio, 0, 0.4)
+	}
+
+	var ratioAlerts, entropyAlerts int
+	for _, a := range e.Alerts() {
+		switch a.Message {
+		case "absorption ratio high":
+			ratioAlerts++
+		case "spectral entropy collapse":
+			entropyAlerts++
+		}
+	}
+	if ratioAlerts != 2 {
+		t.Errorf("absorption ratio alerts = %d, want 2 (one per crossing)", ratioAlerts)
+	}
+	if entropyAlerts != 1 {
+		t.Errorf("entropy alerts = %d, want 1 while it stays low", entropyAlerts)
+	}
+}
+
+// TestAbsorptionShiftDurations steps the ratio from 0.5 to 0.6 after a
+// minute and checks that the shift comes out the same whether Compute
+// runs every second or four times as often.
+func TestAbsorptionShiftDurations(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	cfg := config.Absorption{Fraction: 0.25, ShortSec: 10, LongSec: 60}
+	for _, every := range []time.Duration{time.Second, 250 * time.Millisecond} {
+		a := newAbsorption(cfg)
+		var shift float64
+		for at := every; at <= 70*time.Second; at += every {
+			vals := []float64{2, 2, 0, 0} // ratio 0.5
+			if at > 60*time.Second {
+				vals = []float64{2.4, 1.6, 0, 0} // ratio 0.6
+			}
+			_, shift = a.update(vals, t0.Add(at))
+			if at < 20*time.Second && shift != 0 {
+				t.Fatalf("every %v: shift %v at %v, before the history covers twice the short window", every, shift, at)
+			}
+		}
+		// The last 60 s hold 50 s at 0.5 and 10 s at 0.6: mean 0.5167,
+		// standard deviation about 0.0375, so the 10 s mean of 0.6 lies
+		// about 2.2 deviations above.
+		if math.Abs(shift-2.22) > 0.02 {
+			t.Errorf("every %v: shift = %v, want about 2.22", every, shift)
+		}
+	}
+}
+
+func TestAbsorptionFromCovariance(t *testing.T) {
+	e := New([]string{"A", "B", "C", "D"}, 100, config.Alerts{Eigenvalue: 3}, config.Engine{
+		Absorption: config.Absorption{Fraction: 0.25},
+	})
+	withMatrix(e, mathtest.WithSpectrum([]float64{2, 1.5, 0.3, 0.2}))
+	// A covariance whose top eigenvalue takes 9 of the total 12.
+	e.matrix.Cov = mathtest.WithSpectrum([]float64{9, 1, 1, 1})
+	e.computeEigen()
+
+	mode := e.Mode()
+	if math.Abs(mode.AbsorptionRatio-0.75) > 1e-12 {
+		t.Errorf("AbsorptionRatio = %v, want 0.75 from the covariance eigenvalues", mode.AbsorptionRatio)
+	}
+	if math.Abs(mode.MaxEigen-2) > 1e-12 {
+		t.Errorf("MaxEigen = %v, want 2 from the correlation matrix", mode.MaxEigen)
+	}
+}
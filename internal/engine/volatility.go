@@ -0,0 +1,369 @@
+package engine
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"matrixpulse/internal/clock"
+	"matrixpulse/internal/types"
+	"matrixpulse/internal/window"
+)
+
+// returnStats keeps one symbol's close-to-close return sums and their
+// spacing over its window, updated as prices enter it, so Compute reads
+// the volatility in O(1) however long the window is. Like onlineCov it
+// rebuilds the sums from the stored returns once per window length so
+// rounding error cannot build up; the spacing is kept in whole
+// nanoseconds and needs no rebuild.
+type returnStats struct {
+	mu      sync.Mutex
+	rets    []float64 // ring of the returns in the window
+	gaps    []gap     // the spacing behind each return
+	head    int
+	count   int
+	pending int
+
+	sum, sumSq float64
+	spacing    spacing
+
+	last   float64
+	lastAt time.Time
+	primed bool
+}
+
+func newReturnStats(winSize int) *returnStats {
+	size := max(winSize-1, 1)
+	return &returnStats{rets: make([]float64, size), gaps: make([]gap, size)}
+}
+
+// push records a price as it enters the window.
+func (s *returnStats) push(price float64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.primed {
+		r := math.Log(price / s.last)
+		g := tradingGap(s.lastAt, at)
+		k := (s.head + s.count) % len(s.rets)
+		if s.count == len(s.rets) {
+			old := s.rets[s.head]
+			s.sum -= old
+			s.sumSq -= old * old
+			s.spacing.add(s.gaps[s.head], -1)
+			k = s.head
+			s.head = (s.head + 1) % len(s.rets)
+		} else {
+			s.count++
+		}
+		s.rets[k], s.gaps[k] = r, g
+		s.sum += r
+		s.sumSq += r * r
+		s.spacing.add(g, 1)
+
+		s.pending++
+		if s.pending >= len(s.rets) {
+			s.rebuild()
+		}
+	}
+	s.last, s.lastAt, s.primed = price, at, true
+}
+
+func (s *returnStats) rebuild() {
+	s.sum, s.sumSq = 0, 0
+	for k := 0; k < s.count; k++ {
+		r := s.rets[(s.head+k)%len(s.rets)]
+		s.sum += r
+		s.sumSq += r * r
+	}
+	s.pending = 0
+}
+
+// read returns the latest price, the sample standard deviation of the
+// returns held and the factor annualize gives for their spacing. ok is
+// false until the window holds two returns.
+func (s *returnStats) read(perYear float64) (price, vol, scale float64, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count < 2 {
+		return 0, 0, 0, false
+	}
+	n := float64(s.count)
+	variance := (s.sumSq - s.sum*s.sum/n) / (n - 1)
+	return s.last, math.Sqrt(math.Max(variance, 0)), annualize(s.spacing, perYear), true
+}
+
+// bars holds the most recent OHLC bars for one symbol with running sums
+// of the range estimators' terms and of the bars' spacing. Ingest and
+// Compute run on different goroutines, so each bar is stored whole under
+// one lock and a reading never mixes fields from different bars.
+type bars struct {
+	mu      sync.Mutex
+	buf     []bar
+	idx     int
+	filled  bool
+	pending int
+
+	pk, gk  float64
+	spacing spacing
+}
+
+type bar struct {
+	open, high, low, close float64
+	time                   time.Time
+}
+
+// terms returns the bar's Parkinson and Garman–Klass terms: the squared
+// log range, and half of it less the open-to-close part.
+func (b bar) terms() (pk, gk float64) {
+	hl := math.Log(b.high / b.low)
+	pk = hl * hl
+	gk = 0.5 * hl * hl
+	if b.open > 0 {
+		co := math.Log(b.close / b.open)
+		gk -= (2*math.Ln2 - 1) * co * co
+	}
+	return pk, gk
+}
+
+func newBars(size int) *bars {
+	return &bars{buf: make([]bar, max(size, 2))}
+}
+
+func (b *bars) push(tick types.Tick) {
+	nb := bar{tick.Open, tick.High, tick.Low, tick.Price, tick.Time}
+	size := len(b.buf)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.filled {
+		old := b.buf[b.idx]
+		pk, gk := old.terms()
+		b.pk -= pk
+		b.gk -= gk
+		b.spacing.add(tradingGap(old.time, b.buf[(b.idx+1)%size].time), -1)
+	}
+	if b.filled || b.idx > 0 {
+		prev := b.buf[(b.idx+size-1)%size]
+		b.spacing.add(tradingGap(prev.time, nb.time), 1)
+	}
+	b.buf[b.idx] = nb
+	pk, gk := nb.terms()
+	b.pk += pk
+	b.gk += gk
+
+	b.idx++
+	if b.idx == size {
+		b.idx = 0
+		b.filled = true
+	}
+	b.pending++
+	if b.pending >= size {
+		b.rebuild()
+	}
+}
+
+func (b *bars) rebuild() {
+	b.pk, b.gk = 0, 0
+	for _, bar := range window.Unroll(b.buf, b.idx, b.filled) {
+		pk, gk := bar.terms()
+		b.pk += pk
+		b.gk += gk
+	}
+	b.pending = 0
+}
+
+// computeStats reads each symbol's volatility and raises an alert when
+// the annualized figure crosses above the volatility threshold. It
+// alerts once per crossing; the symbol re-arms when it drops back below.
+// Symbols whose ticks carry no usable timestamps cannot be annualized
+// and are not checked.
+func (e *Engine) computeStats() {
+	stats := make([]types.SymbolStats, 0, len(e.symbols))
+	now := time.Now()
+
+	for _, sym := range e.symbols {
+		price, vol, scale, ok := e.returns[sym].read(e.barsPerYear)
+		if !ok {
+			continue
+		}
+
+		st := types.SymbolStats{
+			Symbol:     sym,
+			Price:      price,
+			Volatility: vol,
+			AnnualVol:  vol * scale,
+			Time:       now,
+		}
+		e.mu.RLock()
+		b := e.bars[sym]
+		e.mu.RUnlock()
+		if b != nil {
+			st.Parkinson, st.GarmanKlass = b.rangeVol(e.barsPerYear)
+		}
+		stats = append(stats, st)
+
+		if t := e.cfg.Volatility; t > 0 && st.AnnualVol > 0 {
+			high := st.AnnualVol > t
+			if high && !e.volHigh[sym] {
+				e.addAlert(types.Alert{
+					Level:     "HIGH",
+					Symbol:    sym,
+					Message:   "volatility spike",
+					Value:     st.AnnualVol,
+					Threshold: t,
+					Time:      now,
+				})
+			}
+			e.volHigh[sym] = high
+		}
+	}
+
+	e.mu.Lock()
+	e.stats = stats
+	e.mu.Unlock()
+}
+
+// rangeVol returns the annualized Parkinson and Garman–Klass volatility
+// of the bars held, or zeros with fewer than two bars. perYear is passed
+// on to annualize.
+func (b *bars) rangeVol(perYear float64) (parkinson, garmanKlass float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := b.idx
+	if b.filled {
+		n = len(b.buf)
+	}
+	if n < 2 {
+		return 0, 0
+	}
+
+	pk := b.pk / (float64(n) * 4 * math.Ln2)
+	gk := b.gk / float64(n)
+	scale := annualize(b.spacing, perYear)
+	return math.Sqrt(math.Max(pk, 0)) * scale, math.Sqrt(math.Max(gk, 0)) * scale
+}
+
+// annualize returns the factor that turns a per-sample volatility into
+// an annual one: √perYear when that is set, otherwise scaled from the
+// typical trading-time step of sp to clock.TradingYear. It is 0 when the
+// step cannot be measured.
+func annualize(sp spacing, perYear float64) float64 {
+	if perYear > 0 {
+		return math.Sqrt(perYear)
+	}
+	if sp.untimed > 0 {
+		return 0
+	}
+	step := sp.step()
+	if step <= 0 {
+		return 0
+	}
+	return math.Sqrt(float64(clock.TradingYear) / float64(step))
+}
+
+// gap is the spacing between two consecutive timestamps in trading time,
+// as spacing counts it.
+type gap struct {
+	kind gapKind
+	d    time.Duration
+}
+
+type gapKind uint8
+
+const (
+	gapNone    gapKind = iota // out of order, or across a weekend only
+	gapWithin                 // within one UTC day
+	gapAcross                 // across days
+	gapUntimed                // a zero timestamp
+)
+
+// tradingGap measures the gap from a to b. A gap within one UTC day
+// counts as it is; a gap across days counts one trading day per weekday
+// it reaches, so nights and weekends between daily bars drop out.
+func tradingGap(a, b time.Time) gap {
+	if a.IsZero() || b.IsZero() {
+		return gap{kind: gapUntimed}
+	}
+	na, nb := a.UnixNano(), b.UnixNano()
+	if nb < na {
+		return gap{}
+	}
+	da, db := unixDay(na), unixDay(nb)
+	if da == db {
+		return gap{gapWithin, time.Duration(nb - na)}
+	}
+	if w := weekdays(da, db); w > 0 {
+		return gap{gapAcross, time.Duration(w) * clock.TradingDay}
+	}
+	return gap{}
+}
+
+// spacing totals the gaps between consecutive timestamps of a window by
+// kind. Gaps are added as values enter the window and removed, with sign
+// -1, as they leave it.
+type spacing struct {
+	within, across   time.Duration
+	nWithin, nAcross int
+	untimed          int
+}
+
+func (s *spacing) add(g gap, sign int) {
+	switch g.kind {
+	case gapWithin:
+		s.within += time.Duration(sign) * g.d
+		s.nWithin += sign
+	case gapAcross:
+		s.across += time.Duration(sign) * g.d
+		s.nAcross += sign
+	case gapUntimed:
+		s.untimed += sign
+	}
+}
+
+// step is the typical spacing in trading time. The more common kind of
+// gap sets it: the overnight gap between hourly bars is not an hour of
+// trading, and a tick stream that runs past midnight still steps by its
+// tick spacing.
+func (s spacing) step() time.Duration {
+	switch {
+	case s.nWithin > s.nAcross:
+		return s.within / time.Duration(s.nWithin)
+	case s.nAcross > 0:
+		return s.across / time.Duration(s.nAcross)
+	}
+	return 0
+}
+
+// unixDay numbers the UTC day of a Unix time in nanoseconds; day 0,
+// 1 January 1970, was a Thursday.
+func unixDay(ns int64) int64 {
+	const day = int64(24 * time.Hour)
+	if ns < 0 {
+		return (ns+1)/day - 1
+	}
+	return ns / day
+}
+
+// weekdays counts the Monday-to-Friday days in (from, to].
+func weekdays(from, to int64) int64 {
+	days := to - from
+	n := days / 7 * 5
+	for d := from + days/7*7 + 1; d <= to; d++ {
+		// (d+4)%7 is the weekday with Sunday as 0.
+		if wd := (d%7 + 7 + 4) % 7; wd != 0 && wd != 6 {
+			n++
+		}
+	}
+	return n
+}
+
+// Stats returns the volatility figures from the last Compute.
+func (e *Engine) Stats() []types.SymbolStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]types.SymbolStats{}, e.stats...)
+}
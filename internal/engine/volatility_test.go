@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/clock"
+	"matrixpulse/internal/config"
+	m "matrixpulse/internal/math"
+	"matrixpulse/internal/types"
+)
+
+func TestRangeVolConstantBars(t *testing.T) {
+	b := newBars(50)
+	t0 := time.Unix(1700000000, 0)
+	for k := 0; k < 80; k++ {
+		b.push(types.Tick{Open: 100, High: 101, Low: 100, Price: 100, Time: t0.Add(time.Duration(k) * time.Second)})
+	}
+
+	hl := math.Log(1.01)
+	scale := math.Sqrt(float64(clock.TradingYear) / float64(time.Second))
+	wantPK := hl / (2 * math.Sqrt(math.Ln2)) * scale
+	wantGK := hl * math.Sqrt(0.5) * scale
+
+	pk, gk := b.rangeVol(0)
+	if math.Abs(pk-wantPK) > 1e-9*wantPK || math.Abs(gk-wantGK) > 1e-9*wantGK {
+		t.Errorf("rangeVol = %v, %v, want %v, %v", pk, gk, wantPK, wantGK)
+	}
+}
+
+// weekdayTimes returns count timestamps at the given clock times of day
+// (UTC) on consecutive weekdays from Monday 8 January 2024.
+func weekdayTimes(count int, of []time.Duration) []time.Time {
+	var times []time.Time
+	for day := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC); len(times) < count; day = day.AddDate(0, 0, 1) {
+		if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			continue
+		}
+		for _, at := range of {
+			if len(times) < count {
+				times = append(times, day.Add(at))
+			}
+		}
+	}
+	return times
+}
+
+func TestAnnualizeTradingTime(t *testing.T) {
+	// NYSE hours in UTC: bars at 14:30, 15:30, ..., 20:30.
+	var hourly []time.Duration
+	for h := 0; h < 7; h++ {
+		hourly = append(hourly, 14*time.Hour+30*time.Minute+time.Duration(h)*time.Hour)
+	}
+	ticks := make([]time.Time, 4000)
+	for k := range ticks {
+		// 25 ms ticks that run through midnight.
+		ticks[k] = time.Date(2024, 1, 9, 23, 59, 0, 0, time.UTC).Add(time.Duration(k) * 25 * time.Millisecond)
+	}
+	weekly := make([]time.Time, 52)
+	for k := range weekly {
+		weekly[k] = time.Date(2024, 1, 5, 21, 0, 0, 0, time.UTC).AddDate(0, 0, 7*k)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		times   []time.Time
+		perYear float64
+		want    float64
+	}{
+		{"daily", weekdayTimes(60, []time.Duration{21 * time.Hour}), 0, math.Sqrt(252)},
+		{"hourly", weekdayTimes(200, hourly), 0, math.Sqrt(252 * 6.5)},
+		{"ticks", ticks, 0, math.Sqrt(float64(clock.TradingYear) / float64(25*time.Millisecond))},
+		{"weekly", weekly, 0, math.Sqrt(252.0 / 5)},
+		{"bars_per_year", weekdayTimes(60, []time.Duration{0}), 365, math.Sqrt(365)},
+	} {
+		var sp spacing
+		for k := 1; k < len(tc.times); k++ {
+			sp.add(tradingGap(tc.times[k-1], tc.times[k]), 1)
+		}
+		if got := annualize(sp, tc.perYear); math.Abs(got-tc.want) > 1e-9*tc.want {
+			t.Errorf("%s: annualize = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestRangeVolConcurrentPush reads bars while they are being written, as
+// Compute does against Ingest. Run with -race.
+func TestRangeVolConcurrentPush(t *testing.T) {
+	b := newBars(64)
+	t0 := time.Unix(1700000000, 0)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for k := 0; k < 20000; k++ {
+			p := 100 + float64(k%7)
+			b.push(types.Tick{Open: p, High: p + 1, Low: p - 1, Price: p, Time: t0.Add(time.Duration(k) * time.Millisecond)})
+		}
+	}()
+
+	for k := 0; k < 2000; k++ {
+		pk, gk := b.rangeVol(0)
+		if math.IsNaN(pk) || math.IsNaN(gk) || pk < 0 || gk < 0 {
+			t.Fatalf("rangeVol = %v, %v", pk, gk)
+		}
+	}
+	wg.Wait()
+}
+
+// TestVolatilityAlertAnnualized feeds two symbols with the same annual
+// volatility at very different tick rates; both must cross a threshold
+// that a calmer symbol stays under, and each alerts only once.
+func TestVolatilityAlertAnnualized(t *testing.T) {
+	syms := []string{"FAST", "SLOW", "CALM"}
+	e := New(syms, 500, config.Alerts{Volatility: 0.6}, config.Engine{})
+
+	rng := rand.New(rand.NewSource(9))
+	spacing := map[string]time.Duration{"FAST": time.Second, "SLOW": time.Minute, "CALM": time.Second}
+	annual := map[string]float64{"FAST": 0.9, "SLOW": 0.9, "CALM": 0.3}
+	t0 := time.Unix(1700000000, 0)
+
+	for _, sym := range syms {
+		sigma := annual[sym] * math.Sqrt(float64(spacing[sym])/float64(clock.TradingYear))
+		price := 100.0
+		for k := 0; k < 500; k++ {
+			price *= math.Exp(sigma * rng.NormFloat64())
+			e.Ingest(types.Tick{Symbol: sym, Price: price, Time: t0.Add(time.Duration(k) * spacing[sym])})
+		}
+	}
+	e.Compute()
+	e.Compute()
+
+	alerted := map[string]int{}
+	for _, a := range e.Alerts() {
+		if a.Message == "volatility spike" {
+			alerted[a.Symbol]++
+		}
+	}
+	if alerted["FAST"] != 1 || alerted["SLOW"] != 1 || alerted["CALM"] != 0 {
+		t.Errorf("volatility alerts = %v, want one each for FAST and SLOW", alerted)
+	}
+}
+
+// TestStatsMatchWindow checks the running return sums against a direct
+// pass over the window after it has wrapped several times, with ticks
+// spread across days so both kinds of gap come and go.
+func TestStatsMatchWindow(t *testing.T) {
+	syms := []string{"A", "B"}
+	e := New(syms, 50, config.Alerts{}, config.Engine{})
+	rng := rand.New(rand.NewSource(13))
+	prices := []float64{100, 20}
+	at := time.Date(2024, 1, 5, 20, 0, 0, 0, time.UTC)
+	for k := 0; k < 437; k++ {
+		at = at.Add(time.Duration(1+rng.Intn(3)) * time.Hour)
+		for i, sym := range syms {
+			prices[i] *= math.Exp(0.02 * rng.NormFloat64())
+			e.Ingest(types.Tick{Symbol: sym, Price: prices[i], Time: at})
+		}
+	}
+	e.Compute()
+
+	stats := e.Stats()
+	if len(stats) != len(syms) {
+		t.Fatalf("%d stats, want %d", len(stats), len(syms))
+	}
+	for i, st := range stats {
+		window, times := e.windows[syms[i]].SnapshotTimes()
+		returns := m.LogReturns(window)
+		vol := m.StdDev(returns, m.Mean(returns))
+		var sp spacing
+		for k := 1; k < len(times); k++ {
+			sp.add(tradingGap(times[k-1], times[k]), 1)
+		}
+		annual := vol * annualize(sp, 0)
+
+		if st.Price != window[len(window)-1] || math.Abs(st.Volatility-vol) > 1e-12 || math.Abs(st.AnnualVol-annual) > 1e-9*annual {
+			t.Errorf("%s: stats %+v, want volatility %v and annualized %v", st.Symbol, st, vol, annual)
+		}
+	}
+}
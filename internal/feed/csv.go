@@ -0,0 +1,408 @@
+package feed
+
+import (
+	"container/heap"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"matrixpulse/internal/config"
+)
+
+func init() {
+	Register("csv", func(symbols []string, cfg config.Feed) (Source, error) {
+		return NewCSV(symbols, cfg.CSV)
+	})
+}
+
+// CSV replays historical ticks or bars from CSV files. Each file must be
+// in timestamp order, and replay stops with an error at the first row
+// that goes back in time; ticks from all files are merged so the output
+// as a whole is in timestamp order. Bar files replay with the price
+// column mapped to the close.
+type CSV struct {
+	sourceBase
+	files   []string
+	cfg     config.CSVFeed
+	symbols map[string]bool
+}
+
+func NewCSV(symbols []string, cfg config.CSVFeed) (*CSV, error) {
+	var files []string
+	for _, pattern := range cfg.Files {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad csv file pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no csv files match %q", pattern)
+		}
+		files = append(files, matches...)
+	}
+
+	want := make(map[string]bool, len(symbols))
+	for _, sym := range symbols {
+		want[sym] = true
+	}
+
+	return &CSV{files: files, cfg: cfg, symbols: want}, nil
+}
+
+func (c *CSV) Name() string { return "csv" }
+
+func (c *CSV) Start(ctx context.Context) <-chan Tick {
+	ctx = c.run(ctx)
+	out := make(chan Tick, 256)
+
+	go func() {
+		defer close(out)
+		defer c.setConnected(false)
+
+		if err := c.replay(ctx, out); err != nil && ctx.Err() == nil {
+			c.recordError(err)
+			log.Printf("csv replay stopped: %v", err)
+			return
+		}
+		log.Printf("csv replay finished (%d ticks)", c.Health().Ticks)
+	}()
+
+	return out
+}
+
+func (c *CSV) replay(ctx context.Context, out chan<- Tick) error {
+	h := make(csvHeap, 0, len(c.files))
+	for i, path := range c.files {
+		r, err := openCSV(path, i, c.cfg)
+		if err != nil {
+			return err
+		}
+		defer r.close()
+
+		if ok, err := c.advance(r); err != nil {
+			return err
+		} else if ok {
+			h = append(h, r)
+		}
+	}
+	heap.Init(&h)
+
+	p := newPacer(c.cfg.Speed)
+	for h.Len() > 0 {
+		r := h[0]
+		tick := r.head
+
+		if err := p.wait(ctx, tick.Time); err != nil {
+			return err
+		}
+
+		select {
+		case out <- tick:
+			c.recordTick(tick.Time)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		ok, err := c.advance(r)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+
+	return nil
+}
+
+// advance loads the next wanted tick from r into r.head, skipping rows
+// for untracked symbols and logging malformed ones. It reports false at
+// end of file.
+func (c *CSV) advance(r *csvReader) (bool, error) {
+	for {
+		tick, err := r.next()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			if _, ok := err.(*rowError); ok {
+				c.recordError(err)
+				log.Printf("csv: %v", err)
+				continue
+			}
+			return false, err
+		}
+		if !c.symbols[tick.Symbol] {
+			continue
+		}
+		r.head = tick
+		return true, nil
+	}
+}
+
+// rowError marks a single unparseable row; replay skips it and carries on.
+type rowError struct {
+	path string
+	line int
+	err  error
+}
+
+func (e *rowError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.path, e.line, e.err)
+}
+
+type csvReader struct {
+	path   string
+	order  int
+	f      *os.File
+	r      *csv.Reader
+	cols   csvColumns
+	layout string
+	symbol string
+	head   Tick
+	last   time.Time // latest timestamp read, for the order check
+}
+
+// csvColumns holds column indexes; -1 means the column is absent.
+type csvColumns struct {
+	symbol, timestamp, price, volume int
+	open, high, low                  int
+}
+
+func openCSV(path string, order int, cfg config.CSVFeed) (*csvReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open csv file: %w", err)
+	}
+
+	r := csv.NewReader(f)
+	r.ReuseRecord = true
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read csv header of %s: %w", path, err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	cols := csvColumns{symbol: -1, timestamp: -1, price: -1, volume: -1, open: -1, high: -1, low: -1}
+	for _, c := range []struct {
+		dst      *int
+		name     string
+		required bool
+	}{
+		{&cols.symbol, cfg.Columns.Symbol, false},
+		{&cols.timestamp, cfg.Columns.Timestamp, true},
+		{&cols.price, cfg.Columns.Price, true},
+		{&cols.volume, cfg.Columns.Volume, false},
+		{&cols.open, cfg.Columns.Open, false},
+		{&cols.high, cfg.Columns.High, false},
+		{&cols.low, cfg.Columns.Low, false},
+	} {
+		i, ok := index[strings.ToLower(c.name)]
+		if ok && c.name != "" {
+			*c.dst = i
+		} else if c.required {
+			f.Close()
+			return nil, fmt.Errorf("%s: missing column %q", path, c.name)
+		}
+	}
+
+	cr := &csvReader{
+		path:   path,
+		order:  order,
+		f:      f,
+		r:      r,
+		cols:   cols,
+		layout: cfg.TimeFormat,
+	}
+	if cols.symbol < 0 {
+		// One symbol per file, named after the file.
+		cr.symbol = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return cr, nil
+}
+
+func (r *csvReader) next() (Tick, error) {
+	rec, err := r.r.Read()
+	if err == io.EOF {
+		return Tick{}, io.EOF
+	}
+	if err != nil {
+		if pe, ok := err.(*csv.ParseError); ok {
+			return Tick{}, &rowError{path: r.path, line: pe.Line, err: pe.Err}
+		}
+		return Tick{}, fmt.Errorf("failed to read %s: %w", r.path, err)
+	}
+	line, _ := r.r.FieldPos(0)
+
+	field := func(i int) (string, error) {
+		if i >= len(rec) {
+			return "", fmt.Errorf("row has %d fields, need column %d", len(rec), i+1)
+		}
+		return strings.TrimSpace(rec[i]), nil
+	}
+	bad := func(err error) (Tick, error) {
+		return Tick{}, &rowError{path: r.path, line: line, err: err}
+	}
+
+	tick := Tick{Symbol: r.symbol}
+	if r.cols.symbol >= 0 {
+		if tick.Symbol, err = field(r.cols.symbol); err != nil {
+			return bad(err)
+		}
+	}
+
+	ts, err := field(r.cols.timestamp)
+	if err != nil {
+		return bad(err)
+	}
+	if tick.Time, err = parseTime(ts, r.layout); err != nil {
+		return bad(err)
+	}
+	// A newest-first export, or one grouped by symbol, would otherwise
+	// replay out of order and pair unrelated returns downstream.
+	if tick.Time.Before(r.last) {
+		return Tick{}, fmt.Errorf("%s:%d: timestamp %s is before the previous row's %s; csv files must be sorted by time",
+			r.path, line, tick.Time.Format(time.RFC3339Nano), r.last.Format(time.RFC3339Nano))
+	}
+	r.last = tick.Time
+
+	s, err := field(r.cols.price)
+	if err != nil {
+		return bad(err)
+	}
+	if tick.Price, err = strconv.ParseFloat(s, 64); err != nil {
+		return bad(fmt.Errorf("bad price %q", s))
+	}
+
+	for _, opt := range []struct {
+		col  int
+		dst  *float64
+		name string
+	}{
+		{r.cols.volume, &tick.Volume, "volume"},
+		{r.cols.open, &tick.Open, "open"},
+		{r.cols.high, &tick.High, "high"},
+		{r.cols.low, &tick.Low, "low"},
+	} {
+		if opt.col < 0 {
+			continue
+		}
+		if s, err = field(opt.col); err != nil {
+			return bad(err)
+		}
+		if s != "" {
+			if *opt.dst, err = strconv.ParseFloat(s, 64); err != nil {
+				return bad(fmt.Errorf("bad %s %q", opt.name, s))
+			}
+		}
+	}
+
+	return tick, nil
+}
+
+func (r *csvReader) close() {
+	r.f.Close()
+}
+
+// parseTime interprets s as unix seconds, milliseconds or nanoseconds, or
+// as a time.Parse layout.
+func parseTime(s, layout string) (time.Time, error) {
+	switch layout {
+	case "unix":
+		if t, ok := parseDecimalUnix(s); ok {
+			return t, nil
+		}
+		sec, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bad unix timestamp %q", s)
+		}
+		return time.Unix(0, int64(sec*1e9)), nil
+	case "unix_ms":
+		ms, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bad unix_ms timestamp %q", s)
+		}
+		return time.UnixMilli(ms), nil
+	case "unix_ns":
+		ns, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bad unix_ns timestamp %q", s)
+		}
+		return time.Unix(0, ns), nil
+	}
+
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad timestamp %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// parseDecimalUnix reads plain decimal unix seconds such as
+// "1700000000.25" exactly: through a float64 the nanoseconds of a
+// present-day timestamp are only good to a few hundred. Digits past the
+// ninth decimal are dropped. ok is false for any other form.
+func parseDecimalUnix(s string) (time.Time, bool) {
+	whole, frac, _ := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if len(frac) > 9 {
+		frac = frac[:9]
+	}
+	var ns int64
+	for k := 0; k < 9; k++ {
+		ns *= 10
+		if k < len(frac) {
+			if frac[k] < '0' || frac[k] > '9' {
+				return time.Time{}, false
+			}
+			ns += int64(frac[k] - '0')
+		}
+	}
+	if strings.HasPrefix(whole, "-") {
+		ns = -ns
+	}
+	return time.Unix(sec, ns), true
+}
+
+// csvHeap orders file readers by the timestamp of their pending tick,
+// falling back to file order so ties replay deterministically.
+type csvHeap []*csvReader
+
+func (h csvHeap) Len() int { return len(h) }
+
+func (h csvHeap) Less(i, j int) bool {
+	if h[i].head.Time.Equal(h[j].head.Time) {
+		return h[i].order < h[j].order
+	}
+	return h[i].head.Time.Before(h[j].head.Time)
+}
+
+func (h csvHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *csvHeap) Push(x interface{}) { *h = append(*h, x.(*csvReader)) }
+
+func (h *csvHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	r := old[n-1]
+	*h = old[:n-1]
+	return r
+}
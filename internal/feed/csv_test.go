@@ -0,0 +1,145 @@
+package feed
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+)
+
+func TestParseTime(t *testing.T) {
+	for _, tc := range []struct {
+		s, layout string
+		want      time.Time
+		bad       bool
+	}{
+		{"1700000000", "unix", time.Unix(1700000000, 0), false},
+		{"1700000000.25", "unix", time.Unix(1700000000, 250_000_000), false},
+		{"1700000000.123456789", "unix", time.Unix(1700000000, 123456789), false},
+		{"-1.5", "unix", time.Unix(-1, -500_000_000), false},
+		{"1.7e9", "unix", time.Unix(1700000000, 0), false},
+		{"1700000000250", "unix_ms", time.UnixMilli(1700000000250), false},
+		{"1700000000000000123", "unix_ns", time.Unix(1700000000, 123), false},
+		{"2024-01-02T14:30:00.5Z", time.RFC3339Nano, time.Date(2024, 1, 2, 14, 30, 0, 500_000_000, time.UTC), false},
+		{"2024-01-02 14:30:00", "2006-01-02 15:04:05", time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC), false},
+		{"yesterday", "unix", time.Time{}, true},
+		{"1.5", "unix_ms", time.Time{}, true},
+		{"2024-01-02", time.RFC3339, time.Time{}, true},
+	} {
+		got, err := parseTime(tc.s, tc.layout)
+		if tc.bad {
+			if err == nil {
+				t.Errorf("parseTime(%q, %q) = %v, want an error", tc.s, tc.layout, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("parseTime(%q, %q) = %v, %v, want %v", tc.s, tc.layout, got, err, tc.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCSVReplay merges a per-symbol file, named after its symbol, with a
+// multi-symbol one. Header names match whatever their case and spacing,
+// rows for untracked symbols are skipped and a malformed row is counted
+// and passed over.
+func TestCSVReplay(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "AAPL.csv"), " Time ,PRICE,Qty\n"+
+		"1700000001,190.5,100\n"+
+		"1700000003,191,\n")
+	writeFile(t, filepath.Join(dir, "multi.csv"), "ticker,time,price,qty\n"+
+		"MSFT,1700000001,370,5\n"+
+		"TSLA,1700000002,240,1\n"+
+		"MSFT,1700000002,abc,1\n"+
+		"MSFT,1700000004,371,2\n")
+
+	src, err := NewCSV([]string{"AAPL", "MSFT"}, config.CSVFeed{
+		Files:      []string{filepath.Join(dir, "AAPL.csv"), filepath.Join(dir, "multi.csv")},
+		Columns:    config.CSVColumns{Symbol: "ticker", Timestamp: "time", Price: "price", Volume: "qty"},
+		TimeFormat: "unix",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got []Tick
+	for tick := range src.Start(ctx) {
+		got = append(got, tick)
+	}
+	want := []Tick{
+		// Same time: file order breaks the tie.
+		{Symbol: "AAPL", Price: 190.5, Volume: 100, Time: time.Unix(1700000001, 0)},
+		{Symbol: "MSFT", Price: 370, Volume: 5, Time: time.Unix(1700000001, 0)},
+		{Symbol: "AAPL", Price: 191, Time: time.Unix(1700000003, 0)},
+		{Symbol: "MSFT", Price: 371, Volume: 2, Time: time.Unix(1700000004, 0)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("replayed %d ticks, want %d: %+v", len(got), len(want), got)
+	}
+	for k := range want {
+		if got[k].Symbol != want[k].Symbol || got[k].Price != want[k].Price ||
+			got[k].Volume != want[k].Volume || !got[k].Time.Equal(want[k].Time) {
+			t.Errorf("tick %d = %+v, want %+v", k, got[k], want[k])
+		}
+	}
+	if h := src.Health(); h.Errors != 1 || h.Ticks != 4 {
+		t.Errorf("health = %+v, want 4 ticks and 1 error", h)
+	}
+}
+
+// TestCSVTimeRegression replays a file grouped by symbol, whose times
+// restart at the second symbol. Replay must stop there with an error
+// rather than emit ticks out of order.
+func TestCSVTimeRegression(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.csv")
+	writeFile(t, path, "symbol,time,price\n"+
+		"AAPL,1700000001,190\n"+
+		"AAPL,1700000002,191\n"+
+		"MSFT,1700000001,370\n"+
+		"MSFT,1700000002,371\n")
+
+	src, err := NewCSV([]string{"AAPL", "MSFT"}, config.CSVFeed{
+		Files:      []string{path},
+		Columns:    config.CSVColumns{Symbol: "symbol", Timestamp: "time", Price: "price"},
+		TimeFormat: "unix",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got []Tick
+	for tick := range src.Start(ctx) {
+		got = append(got, tick)
+	}
+	if len(got) != 2 || got[0].Symbol != "AAPL" || got[1].Symbol != "AAPL" {
+		t.Errorf("replayed %+v, want the two AAPL ticks only", got)
+	}
+	if h := src.Health(); h.Errors != 1 || !strings.Contains(h.LastError, "grouped.csv:4") {
+		t.Errorf("health = %+v, want the regression at line 4 reported", h)
+	}
+}
+
+func TestCSVMissingColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "AAPL.csv")
+	writeFile(t, path, "time,last\n1700000001,190.5\n")
+	_, err := openCSV(path, 0, config.CSVFeed{Columns: config.CSVColumns{Timestamp: "time", Price: "price"}})
+	if err == nil || !strings.Contains(err.Error(), `missing column "price"`) {
+		t.Errorf("openCSV error = %v, want the missing price column named", err)
+	}
+}
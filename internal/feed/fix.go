@@ -0,0 +1,426 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/fix"
+)
+
+const fixBeginString = "FIX.4.4"
+
+func init() {
+	Register("fix", func(symbols []string, cfg config.Feed) (Source, error) {
+		return NewFIX(symbols, cfg.FIX), nil
+	})
+}
+
+// FIX is a FIX 4.4 initiator that subscribes to market data for the
+// configured symbols. Snapshots (35=W) and incremental refreshes (35=X)
+// update a top-of-book per symbol, and each update is emitted either as
+// the bid/offer mid or, in trade mode, as the last trade.
+//
+// The session logs on, keeps the link alive with heartbeats and test
+// requests, and recovers from inbound sequence gaps with a resend
+// request. Outbound messages are not stored, so resend requests from the
+// counterparty are answered with a gap fill.
+type FIX struct {
+	sourceBase
+	cfg     config.FIXFeed
+	symbols []string
+	tracked map[string]bool
+
+	// Sequence numbers survive reconnects unless ResetSeqNum is set.
+	outSeq int
+	inSeq  int
+}
+
+func NewFIX(symbols []string, cfg config.FIXFeed) *FIX {
+	f := &FIX{
+		cfg:     cfg,
+		symbols: symbols,
+		tracked: make(map[string]bool, len(symbols)),
+		outSeq:  1,
+		inSeq:   1,
+	}
+	for _, sym := range symbols {
+		f.tracked[sym] = true
+	}
+	return f
+}
+
+func (f *FIX) Name() string { return "fix" }
+
+func (f *FIX) Start(ctx context.Context) <-chan Tick {
+	ctx = f.run(ctx)
+	f.setConnected(false)
+	out := make(chan Tick, 1024)
+
+	go func() {
+		defer close(out)
+		defer f.setConnected(false)
+
+		retry := time.Duration(f.cfg.ReconnectSec) * time.Second
+		for {
+			err := f.session(ctx, out)
+			if ctx.Err() != nil {
+				return
+			}
+			f.setConnected(false)
+			f.recordError(err)
+			log.Printf("fix: %v (reconnecting in %v)", err, retry)
+
+			timer := time.NewTimer(retry)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+
+	return out
+}
+
+// fixSession is the state of one connection.
+type fixSession struct {
+	*FIX
+	conn     net.Conn
+	out      chan<- Tick
+	loggedOn bool
+	book     map[string]*topOfBook
+
+	// resendTo is the highest sequence number we are waiting to have
+	// resent, or 0 when no resend is outstanding.
+	resendTo int
+	lastSent time.Time
+	lastRecv time.Time
+	testReq  string
+}
+
+type topOfBook struct {
+	bid, ask float64
+}
+
+func (f *FIX) session(ctx context.Context, out chan<- Tick) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", f.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("dial failed: %w", err)
+	}
+	defer conn.Close()
+
+	if f.cfg.ResetSeqNum {
+		f.outSeq, f.inSeq = 1, 1
+	}
+
+	s := &fixSession{
+		FIX:      f,
+		conn:     conn,
+		out:      out,
+		book:     make(map[string]*topOfBook),
+		lastRecv: time.Now(),
+	}
+
+	// The reader lives only as long as this connection; cancelling its
+	// context on return releases it even when it is blocked handing over
+	// a message nobody will read.
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs := make(chan *fix.Message)
+	readErr := make(chan error, 1)
+	go func() {
+		r := fix.NewReader(conn)
+		for {
+			m, err := r.Read()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case msgs <- m:
+			case <-readCtx.Done():
+				return
+			}
+		}
+	}()
+
+	logon := fix.New(fix.MsgLogon).
+		Add(fix.TagEncryptMethod, "0").
+		AddInt(fix.TagHeartBtInt, f.cfg.HeartbeatSec)
+	if f.cfg.ResetSeqNum {
+		logon.Add(fix.TagResetSeqNumFlag, "Y")
+	}
+	if f.cfg.Username != "" {
+		logon.Add(fix.TagUsername, f.cfg.Username).Add(fix.TagPassword, f.cfg.Password)
+	}
+	if err := s.send(logon); err != nil {
+		return err
+	}
+
+	hb := time.Duration(f.cfg.HeartbeatSec) * time.Second
+	ticker := time.NewTicker(hb / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.send(fix.New(fix.MsgLogout))
+			return ctx.Err()
+		case err := <-readErr:
+			return fmt.Errorf("read failed: %w", err)
+		case m := <-msgs:
+			s.lastRecv = time.Now()
+			s.testReq = ""
+			if err := s.handle(ctx, m); err != nil {
+				return err
+			}
+		case now := <-ticker.C:
+			if err := s.keepAlive(now, hb); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// keepAlive sends heartbeats when we have been quiet and probes a quiet
+// counterparty with a test request, giving up if that goes unanswered.
+func (s *fixSession) keepAlive(now time.Time, hb time.Duration) error {
+	if now.Sub(s.lastSent) >= hb {
+		if err := s.send(fix.New(fix.MsgHeartbeat)); err != nil {
+			return err
+		}
+	}
+
+	silent := now.Sub(s.lastRecv)
+	switch {
+	case s.testReq != "" && silent >= 2*hb+hb/5:
+		return fmt.Errorf("counterparty silent for %v", silent.Round(time.Second))
+	case s.testReq == "" && silent >= hb+hb/5:
+		s.testReq = "TEST-" + strconv.FormatInt(now.UnixNano(), 36)
+		return s.send(fix.New(fix.MsgTestRequest).Add(fix.TagTestReqID, s.testReq))
+	}
+	return nil
+}
+
+func (s *fixSession) send(m *fix.Message) error {
+	header := []fix.Field{
+		m.Fields[0],
+		{Tag: fix.TagSenderCompID, Value: s.cfg.SenderCompID},
+		{Tag: fix.TagTargetCompID, Value: s.cfg.TargetCompID},
+		{Tag: fix.TagMsgSeqNum, Value: strconv.Itoa(s.outSeq)},
+		{Tag: fix.TagSendingTime, Value: time.Now().UTC().Format(fix.TimestampFormat)},
+	}
+	m.Fields = append(header, m.Fields[1:]...)
+
+	s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := s.conn.Write(fix.Encode(fixBeginString, m)); err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	s.outSeq++
+	s.lastSent = time.Now()
+	return nil
+}
+
+func (s *fixSession) handle(ctx context.Context, m *fix.Message) error {
+	seq := m.Int(fix.TagMsgSeqNum)
+	typ := m.Type()
+
+	// A reset-mode SequenceReset applies regardless of its own number.
+	if typ == fix.MsgSequenceReset && !m.Flag(fix.TagGapFillFlag) {
+		s.inSeq = m.Int(fix.TagNewSeqNo)
+		s.resendTo = 0
+		return nil
+	}
+
+	switch {
+	case seq > s.inSeq:
+		// Gap: ask for the missing range once, and only act on messages
+		// that must not wait for it. Everything else is resent to us.
+		if s.resendTo == 0 {
+			log.Printf("fix: sequence gap, expected %d got %d", s.inSeq, seq)
+			if err := s.send(fix.New(fix.MsgResendRequest).
+				AddInt(fix.TagBeginSeqNo, s.inSeq).
+				AddInt(fix.TagEndSeqNo, 0)); err != nil {
+				return err
+			}
+		}
+		if seq > s.resendTo {
+			s.resendTo = seq
+		}
+		switch typ {
+		case fix.MsgLogon, fix.MsgLogout, fix.MsgTestRequest, fix.MsgResendRequest:
+			return s.process(ctx, m)
+		}
+		return nil
+
+	case seq < s.inSeq:
+		if m.Flag(fix.TagPossDupFlag) {
+			return nil
+		}
+		s.send(fix.New(fix.MsgLogout).Add(fix.TagText, fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", s.inSeq, seq)))
+		return fmt.Errorf("inbound sequence too low (expected %d, got %d)", s.inSeq, seq)
+	}
+
+	if typ == fix.MsgSequenceReset {
+		// Gap fill: skip ahead to the next real message.
+		s.inSeq = m.Int(fix.TagNewSeqNo)
+	} else {
+		s.inSeq++
+	}
+	if s.resendTo != 0 && s.inSeq > s.resendTo {
+		s.resendTo = 0
+	}
+	return s.process(ctx, m)
+}
+
+func (s *fixSession) process(ctx context.Context, m *fix.Message) error {
+	switch m.Type() {
+	case fix.MsgLogon:
+		if !s.loggedOn {
+			s.loggedOn = true
+			s.setConnected(true)
+			log.Printf("fix: logged on to %s", s.cfg.Addr)
+			return s.subscribe()
+		}
+
+	case fix.MsgTestRequest:
+		id, _ := m.Get(fix.TagTestReqID)
+		return s.send(fix.New(fix.MsgHeartbeat).Add(fix.TagTestReqID, id))
+
+	case fix.MsgResendRequest:
+		// Nothing is stored for replay, so gap-fill the requested range.
+		begin := m.Int(fix.TagBeginSeqNo)
+		next := s.outSeq
+		gap := fix.New(fix.MsgSequenceReset).
+			Add(fix.TagGapFillFlag, "Y").
+			Add(fix.TagPossDupFlag, "Y").
+			AddInt(fix.TagNewSeqNo, next)
+		s.outSeq = begin
+		err := s.send(gap)
+		s.outSeq = next
+		return err
+
+	case fix.MsgReject, fix.MsgMDRequestReject:
+		text, _ := m.Get(fix.TagText)
+		err := fmt.Errorf("rejected (35=%s, ref %d): %s", m.Type(), m.Int(fix.TagRefSeqNum), text)
+		s.recordError(err)
+		log.Printf("fix: %v", err)
+
+	case fix.MsgLogout:
+		text, _ := m.Get(fix.TagText)
+		return fmt.Errorf("logged out by counterparty: %s", text)
+
+	case fix.MsgMDSnapshot, fix.MsgMDIncremental:
+		return s.marketData(ctx, m)
+	}
+	return nil
+}
+
+func (s *fixSession) subscribe() error {
+	req := fix.New(fix.MsgMDRequest).
+		Add(fix.TagMDReqID, "matrixpulse-"+strconv.FormatInt(time.Now().UnixNano(), 36)).
+		Add(fix.TagSubscriptionReq, "1").
+		AddInt(fix.TagMarketDepth, s.cfg.MarketDepth).
+		Add(fix.TagMDUpdateType, "1").
+		AddInt(fix.TagNoMDEntryTypes, 3).
+		Add(fix.TagMDEntryType, "0").
+		Add(fix.TagMDEntryType, "1").
+		Add(fix.TagMDEntryType, "2").
+		AddInt(fix.TagNoRelatedSym, len(s.symbols))
+	for _, sym := range s.symbols {
+		req.Add(fix.TagSymbol, sym)
+	}
+	return s.send(req)
+}
+
+// marketData applies snapshot or incremental entries to the book and
+// emits the resulting ticks. Entries for price levels below the top
+// (MDEntryPositionNo above 1), which a venue may send regardless of the
+// requested depth, are skipped so they never displace the best bid or
+// offer.
+func (s *fixSession) marketData(ctx context.Context, m *fix.Message) error {
+	ts, ok := m.Time(fix.TagSendingTime)
+	if !ok {
+		ts = time.Now()
+	}
+
+	snapshot := m.Type() == fix.MsgMDSnapshot
+	delim := fix.TagMDUpdateAction
+	symbol, _ := m.Get(fix.TagSymbol)
+	if snapshot {
+		delim = fix.TagMDEntryType
+		s.book[symbol] = &topOfBook{}
+	}
+
+	for _, entry := range m.Group(fix.TagNoMDEntries, delim) {
+		var typ, action string
+		var px, size float64
+		level := 1
+		sym := symbol
+		for _, f := range entry {
+			switch f.Tag {
+			case fix.TagMDEntryType:
+				typ = f.Value
+			case fix.TagMDUpdateAction:
+				action = f.Value
+			case fix.TagSymbol:
+				sym = f.Value
+			case fix.TagMDEntryPx:
+				px, _ = strconv.ParseFloat(f.Value, 64)
+			case fix.TagMDEntrySize:
+				size, _ = strconv.ParseFloat(f.Value, 64)
+			case fix.TagMDEntryPosNo:
+				level, _ = strconv.Atoi(f.Value)
+			}
+		}
+		if !s.tracked[sym] || level > 1 {
+			continue
+		}
+
+		book := s.book[sym]
+		if book == nil {
+			book = &topOfBook{}
+			s.book[sym] = book
+		}
+		if action == "2" {
+			px = 0
+		}
+
+		var price float64
+		switch typ {
+		case "0":
+			book.bid = px
+			if s.cfg.PriceSource == "mid" && book.bid > 0 && book.ask > 0 {
+				price = (book.bid + book.ask) / 2
+			}
+		case "1":
+			book.ask = px
+			if s.cfg.PriceSource == "mid" && book.bid > 0 && book.ask > 0 {
+				price = (book.bid + book.ask) / 2
+			}
+		case "2":
+			if s.cfg.PriceSource == "trade" {
+				price = px
+			}
+		}
+		if price <= 0 {
+			continue
+		}
+
+		select {
+		case s.out <- Tick{Symbol: sym, Price: price, Volume: size, Time: ts}:
+			s.recordTick(ts)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
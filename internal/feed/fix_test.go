@@ -0,0 +1,204 @@
+package feed
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/fix"
+)
+
+// fixAcceptor is a scripted stand-in for a FIX market data acceptor. The
+// test drives it step by step: expect reads the initiator's next message
+// (skipping heartbeats unless asked for) and send writes one stamped
+// with the given sequence number.
+type fixAcceptor struct {
+	t    *testing.T
+	ln   net.Listener
+	conn net.Conn
+	r    *fix.Reader
+}
+
+func newFIXAcceptor(t *testing.T) *fixAcceptor {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return &fixAcceptor{t: t, ln: ln}
+}
+
+func (a *fixAcceptor) accept() {
+	a.t.Helper()
+	a.ln.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, err := a.ln.Accept()
+	if err != nil {
+		a.t.Fatalf("accept: %v", err)
+	}
+	a.t.Cleanup(func() { conn.Close() })
+	a.conn, a.r = conn, fix.NewReader(conn)
+}
+
+func (a *fixAcceptor) read() *fix.Message {
+	a.t.Helper()
+	a.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	m, err := a.r.Read()
+	if err != nil {
+		a.t.Fatalf("acceptor read: %v", err)
+	}
+	return m
+}
+
+// expect returns the next message of type typ, failing on anything else
+// but heartbeats.
+func (a *fixAcceptor) expect(typ string) *fix.Message {
+	a.t.Helper()
+	for {
+		m := a.read()
+		if m.Type() == typ {
+			return m
+		}
+		if m.Type() != fix.MsgHeartbeat {
+			a.t.Fatalf("got 35=%s, want 35=%s", m.Type(), typ)
+		}
+	}
+}
+
+func (a *fixAcceptor) send(seq int, m *fix.Message) {
+	a.t.Helper()
+	header := []fix.Field{
+		m.Fields[0],
+		{Tag: fix.TagSenderCompID, Value: "VENUE"},
+		{Tag: fix.TagTargetCompID, Value: "MP"},
+		{Tag: fix.TagMsgSeqNum, Value: strconv.Itoa(seq)},
+		{Tag: fix.TagSendingTime, Value: "20240102-14:30:00.000"},
+	}
+	m.Fields = append(header, m.Fields[1:]...)
+	if _, err := a.conn.Write(fix.Encode(fixBeginString, m)); err != nil {
+		a.t.Fatalf("acceptor write: %v", err)
+	}
+}
+
+func TestFIXSession(t *testing.T) {
+	acc := newFIXAcceptor(t)
+	f := NewFIX([]string{"AAPL", "MSFT"}, config.FIXFeed{
+		Addr:         acc.ln.Addr().String(),
+		SenderCompID: "MP",
+		TargetCompID: "VENUE",
+		Username:     "user",
+		Password:     "secret",
+		HeartbeatSec: 1,
+		MarketDepth:  1,
+		PriceSource:  "mid",
+		ReconnectSec: 1,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ticks := f.Start(ctx)
+
+	// Logon, answered by ours, triggers the market data subscription.
+	acc.accept()
+	logon := acc.expect(fix.MsgLogon)
+	if logon.Int(fix.TagMsgSeqNum) != 1 || logon.Int(fix.TagHeartBtInt) != 1 {
+		t.Errorf("logon = %+v", logon.Fields)
+	}
+	if u, _ := logon.Get(fix.TagUsername); u != "user" {
+		t.Errorf("logon username = %q", u)
+	}
+	acc.send(1, fix.New(fix.MsgLogon).Add(fix.TagEncryptMethod, "0").AddInt(fix.TagHeartBtInt, 1))
+
+	req := acc.expect(fix.MsgMDRequest)
+	if req.Int(fix.TagNoRelatedSym) != 2 {
+		t.Errorf("md request = %+v", req.Fields)
+	}
+
+	// A snapshot sets the book; the mid is emitted once both sides exist.
+	acc.send(2, fix.New(fix.MsgMDSnapshot).
+		Add(fix.TagSymbol, "AAPL").
+		AddInt(fix.TagNoMDEntries, 3).
+		Add(fix.TagMDEntryType, "0").Add(fix.TagMDEntryPx, "100").Add(fix.TagMDEntrySize, "5").
+		Add(fix.TagMDEntryType, "1").Add(fix.TagMDEntryPx, "102").Add(fix.TagMDEntrySize, "7").
+		Add(fix.TagMDEntryType, "2").Add(fix.TagMDEntryPx, "101.5"))
+	wantTick(t, ticks, "AAPL", 101)
+
+	// Seq 5 arrives with 3 and 4 missing: the initiator asks for a resend
+	// from 3 and holds seq 5 back.
+	update := func() *fix.Message {
+		return fix.New(fix.MsgMDIncremental).
+			AddInt(fix.TagNoMDEntries, 2).
+			Add(fix.TagMDUpdateAction, "0").Add(fix.TagMDEntryType, "0").Add(fix.TagSymbol, "MSFT").Add(fix.TagMDEntryPx, "50").
+			Add(fix.TagMDUpdateAction, "0").Add(fix.TagMDEntryType, "1").Add(fix.TagSymbol, "MSFT").Add(fix.TagMDEntryPx, "52")
+	}
+	acc.send(5, update())
+	resend := acc.expect(fix.MsgResendRequest)
+	if resend.Int(fix.TagBeginSeqNo) != 3 || resend.Int(fix.TagEndSeqNo) != 0 {
+		t.Errorf("resend request = %+v", resend.Fields)
+	}
+
+	// Gap-fill 3 up to 4, resend 4, then 5 again.
+	acc.send(3, fix.New(fix.MsgSequenceReset).
+		Add(fix.TagGapFillFlag, "Y").Add(fix.TagPossDupFlag, "Y").AddInt(fix.TagNewSeqNo, 4))
+	acc.send(4, fix.New(fix.MsgMDIncremental).Add(fix.TagPossDupFlag, "Y").
+		AddInt(fix.TagNoMDEntries, 1).
+		Add(fix.TagMDUpdateAction, "1").Add(fix.TagMDEntryType, "0").Add(fix.TagSymbol, "AAPL").Add(fix.TagMDEntryPx, "103"))
+	wantTick(t, ticks, "AAPL", 102.5)
+	acc.send(5, update())
+	wantTick(t, ticks, "MSFT", 51)
+
+	// Our test request must be answered with its ID.
+	acc.send(6, fix.New(fix.MsgTestRequest).Add(fix.TagTestReqID, "PING"))
+	hb := acc.read()
+	if id, _ := hb.Get(fix.TagTestReqID); hb.Type() != fix.MsgHeartbeat || id != "PING" {
+		t.Errorf("test request answer = %+v", hb.Fields)
+	}
+
+	// Silence from us draws heartbeats and then a test request.
+	probe := acc.expect(fix.MsgTestRequest)
+	id, _ := probe.Get(fix.TagTestReqID)
+	if id == "" {
+		t.Error("test request without an ID")
+	}
+	acc.send(7, fix.New(fix.MsgHeartbeat).Add(fix.TagTestReqID, id))
+
+	// Deeper levels, sent despite the requested depth of 1, must not
+	// displace the top of book in snapshots or in incremental updates.
+	acc.send(8, fix.New(fix.MsgMDSnapshot).
+		Add(fix.TagSymbol, "AAPL").
+		AddInt(fix.TagNoMDEntries, 4).
+		Add(fix.TagMDEntryType, "0").Add(fix.TagMDEntryPx, "100").Add(fix.TagMDEntryPosNo, "1").
+		Add(fix.TagMDEntryType, "0").Add(fix.TagMDEntryPx, "98").Add(fix.TagMDEntryPosNo, "2").
+		Add(fix.TagMDEntryType, "1").Add(fix.TagMDEntryPx, "104").Add(fix.TagMDEntryPosNo, "1").
+		Add(fix.TagMDEntryType, "1").Add(fix.TagMDEntryPx, "105").Add(fix.TagMDEntryPosNo, "2"))
+	wantTick(t, ticks, "AAPL", 102)
+	acc.send(9, fix.New(fix.MsgMDIncremental).
+		AddInt(fix.TagNoMDEntries, 2).
+		Add(fix.TagMDUpdateAction, "1").Add(fix.TagMDEntryType, "0").Add(fix.TagSymbol, "AAPL").Add(fix.TagMDEntryPx, "99").Add(fix.TagMDEntryPosNo, "2").
+		Add(fix.TagMDUpdateAction, "1").Add(fix.TagMDEntryType, "0").Add(fix.TagSymbol, "AAPL").Add(fix.TagMDEntryPx, "103").Add(fix.TagMDEntryPosNo, "1"))
+	wantTick(t, ticks, "AAPL", 103.5)
+
+	// A logout ends the session; the initiator reconnects and carries its
+	// sequence numbers over.
+	last := probe.Int(fix.TagMsgSeqNum)
+	acc.send(10, fix.New(fix.MsgLogout).Add(fix.TagText, "maintenance"))
+	acc.accept()
+	relogon := acc.expect(fix.MsgLogon)
+	if got := relogon.Int(fix.TagMsgSeqNum); got <= last {
+		t.Errorf("logon after reconnect has seq %d, want above %d", got, last)
+	}
+
+	if h := f.Health(); h.Ticks != 5 || h.Errors != 1 {
+		t.Errorf("health = %+v, want 5 ticks and the logout as an error", h)
+	}
+}
+
+func wantTick(t *testing.T, ticks <-chan Tick, sym string, price float64) {
+	t.Helper()
+	tick := recvTick(t, ticks)
+	if tick.Symbol != sym || tick.Price != price {
+		t.Errorf("tick = %s %v, want %s %v", tick.Symbol, tick.Price, sym, price)
+	}
+}
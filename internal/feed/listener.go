@@ -0,0 +1,291 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"matrixpulse/internal/config"
+)
+
+func init() {
+	Register("listener", func(symbols []string, cfg config.Feed) (Source, error) {
+		return NewListener(symbols, cfg.Listener)
+	})
+}
+
+// Listener accepts ticks pushed by other processes over TCP and/or UDP.
+// Each line is either "SYMBOL PRICE [VOLUME [TIMESTAMP]]" or a JSON
+// object with symbol, price, volume and time keys. Timestamps are read
+// with the configured time format; ticks without one are stamped on
+// receipt. A UDP datagram may carry several lines. Ticks for symbols
+// not being tracked are dropped.
+type Listener struct {
+	sourceBase
+	cfg     config.ListenerFeed
+	symbols map[string]bool
+	ln      net.Listener
+	pc      net.PacketConn
+}
+
+// NewListener binds the configured addresses, so a port that is taken
+// or not permitted fails here rather than leaving a feed with no data.
+func NewListener(symbols []string, cfg config.ListenerFeed) (*Listener, error) {
+	l := &Listener{cfg: cfg, symbols: make(map[string]bool, len(symbols))}
+	for _, sym := range symbols {
+		l.symbols[sym] = true
+	}
+
+	if cfg.TCPAddr != "" {
+		ln, err := net.Listen("tcp", cfg.TCPAddr)
+		if err != nil {
+			return nil, fmt.Errorf("listener: %w", err)
+		}
+		l.ln = ln
+	}
+
+	if cfg.UDPAddr != "" {
+		pc, err := net.ListenPacket("udp", cfg.UDPAddr)
+		if err != nil {
+			if l.ln != nil {
+				l.ln.Close()
+			}
+			return nil, fmt.Errorf("listener: %w", err)
+		}
+		l.pc = pc
+	}
+
+	return l, nil
+}
+
+func (l *Listener) Name() string { return "listener" }
+
+// Addrs returns the bound TCP and UDP addresses, nil for those not
+// configured. They differ from the configured ones when a port of 0
+// asked the system to choose.
+func (l *Listener) Addrs() (tcp, udp net.Addr) {
+	if l.ln != nil {
+		tcp = l.ln.Addr()
+	}
+	if l.pc != nil {
+		udp = l.pc.LocalAddr()
+	}
+	return tcp, udp
+}
+
+// Stop also releases the sockets, which a listener that was never
+// started would otherwise hold.
+func (l *Listener) Stop() {
+	l.sourceBase.Stop()
+	if l.ln != nil {
+		l.ln.Close()
+	}
+	if l.pc != nil {
+		l.pc.Close()
+	}
+}
+
+func (l *Listener) Start(ctx context.Context) <-chan Tick {
+	ctx = l.run(ctx)
+	out := make(chan Tick, 1024)
+
+	var wg sync.WaitGroup
+	if l.ln != nil {
+		log.Printf("listener: accepting TCP ticks on %s", l.ln.Addr())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serveTCP(ctx, l.ln, out)
+		}()
+	}
+
+	if l.pc != nil {
+		log.Printf("listener: accepting UDP ticks on %s", l.pc.LocalAddr())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serveUDP(ctx, l.pc, out)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		l.setConnected(false)
+		close(out)
+	}()
+
+	return out
+}
+
+func (l *Listener) serveTCP(ctx context.Context, ln net.Listener, out chan<- Tick) {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var conns sync.WaitGroup
+	defer conns.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				l.recordError(err)
+				log.Printf("listener: accept failed: %v", err)
+			}
+			return
+		}
+
+		conns.Add(1)
+		go func() {
+			defer conns.Done()
+			l.serveConn(ctx, conn, out)
+		}()
+	}
+}
+
+func (l *Listener) serveConn(ctx context.Context, conn net.Conn, out chan<- Tick) {
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		if !l.handle(ctx, sc.Bytes(), conn.RemoteAddr(), out) {
+			return
+		}
+	}
+	if err := sc.Err(); err != nil && ctx.Err() == nil {
+		l.recordError(err)
+	}
+}
+
+func (l *Listener) serveUDP(ctx context.Context, pc net.PacketConn, out chan<- Tick) {
+	go func() {
+		<-ctx.Done()
+		pc.Close()
+	}()
+
+	buf := make([]byte, 64<<10)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				l.recordError(err)
+				log.Printf("listener: udp read failed: %v", err)
+			}
+			return
+		}
+
+		for _, line := range bytes.Split(buf[:n], []byte{'\n'}) {
+			if !l.handle(ctx, line, addr, out) {
+				return
+			}
+		}
+	}
+}
+
+// handle parses one line and forwards the tick if its symbol is tracked.
+// It reports false once the listener is shutting down.
+func (l *Listener) handle(ctx context.Context, line []byte, from net.Addr, out chan<- Tick) bool {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return true
+	}
+
+	tick, err := l.parse(line)
+	if err != nil {
+		l.recordError(err)
+		log.Printf("listener: %s: %v", from, err)
+		return true
+	}
+	if !l.symbols[tick.Symbol] {
+		return true
+	}
+
+	select {
+	case out <- tick:
+		l.recordTick(tick.Time)
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *Listener) parse(line []byte) (Tick, error) {
+	if line[0] == '{' {
+		return l.parseJSON(line)
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) < 2 || len(fields) > 4 {
+		return Tick{}, fmt.Errorf("want SYMBOL PRICE [VOLUME [TIMESTAMP]], got %q", line)
+	}
+
+	tick := Tick{Symbol: fields[0], Time: time.Now()}
+	var err error
+	if tick.Price, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return Tick{}, fmt.Errorf("bad price %q", fields[1])
+	}
+	if len(fields) > 2 {
+		if tick.Volume, err = strconv.ParseFloat(fields[2], 64); err != nil {
+			return Tick{}, fmt.Errorf("bad volume %q", fields[2])
+		}
+	}
+	if len(fields) > 3 {
+		if tick.Time, err = parseTime(fields[3], l.cfg.TimeFormat); err != nil {
+			return Tick{}, err
+		}
+	}
+	return tick, nil
+}
+
+func (l *Listener) parseJSON(line []byte) (Tick, error) {
+	var msg struct {
+		Symbol string      `json:"symbol"`
+		Price  json.Number `json:"price"`
+		Volume json.Number `json:"volume"`
+		Time   interface{} `json:"time"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&msg); err != nil {
+		return Tick{}, fmt.Errorf("bad json tick: %w", err)
+	}
+	if msg.Symbol == "" {
+		return Tick{}, fmt.Errorf("json tick without symbol")
+	}
+
+	tick := Tick{Symbol: msg.Symbol, Time: time.Now()}
+	var err error
+	if tick.Price, err = msg.Price.Float64(); err != nil {
+		return Tick{}, fmt.Errorf("bad price %q", msg.Price)
+	}
+	if msg.Volume != "" {
+		if tick.Volume, err = msg.Volume.Float64(); err != nil {
+			return Tick{}, fmt.Errorf("bad volume %q", msg.Volume)
+		}
+	}
+	if msg.Time != nil {
+		if tick.Time, err = parseTime(scalarString(msg.Time), l.cfg.TimeFormat); err != nil {
+			return Tick{}, err
+		}
+	}
+	return tick, nil
+}
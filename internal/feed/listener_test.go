@@ -0,0 +1,66 @@
+package feed
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"matrixpulse/internal/config"
+)
+
+func TestListenerBindFailure(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	_, err = New([]string{"AAPL"}, config.Feed{
+		Type:     "listener",
+		Listener: config.ListenerFeed{TCPAddr: taken.Addr().String()},
+	})
+	if err == nil {
+		t.Fatal("New succeeded on a port already in use")
+	}
+}
+
+func TestListenerTCPAndUDP(t *testing.T) {
+	l, err := NewListener([]string{"AAPL", "MSFT"}, config.ListenerFeed{
+		TCPAddr:    "127.0.0.1:0",
+		UDPAddr:    "127.0.0.1:0",
+		TimeFormat: "unix",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ticks := l.Start(ctx)
+	tcpAddr, udpAddr := l.Addrs()
+
+	tc, err := net.Dial("tcp", tcpAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tc.Close()
+	tc.Write([]byte("AAPL 189.5 300 1700000000\n"))
+
+	if tick := recvTick(t, ticks); tick.Symbol != "AAPL" || tick.Price != 189.5 || tick.Volume != 300 || tick.Time.Unix() != 1700000000 {
+		t.Errorf("tcp tick = %+v", tick)
+	}
+
+	uc, err := net.Dial("udp", udpAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer uc.Close()
+	// IBM is not tracked, so MSFT is the next tick out.
+	uc.Write([]byte("bad line with far too many fields\nIBM 150\n{\"symbol\":\"MSFT\",\"price\":\"410.25\"}\n"))
+
+	if tick := recvTick(t, ticks); tick.Symbol != "MSFT" || tick.Price != 410.25 {
+		t.Errorf("udp tick = %+v", tick)
+	}
+	if h := l.Health(); h.Errors != 1 {
+		t.Errorf("errors = %d, want 1 for the bad line", h.Errors)
+	}
+}
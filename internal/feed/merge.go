@@ -0,0 +1,285 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+func init() {
+	Register("merge", func(symbols []string, cfg config.Feed) (Source, error) {
+		return NewMerge(symbols, cfg.Merge)
+	})
+}
+
+// Merge runs several sources side by side and, per symbol, forwards ticks
+// only from the highest-priority source that is healthy: connected and
+// having delivered that symbol within the stale window. When the active
+// source goes stale the symbol fails over to the next healthy one, and
+// moves back once the preferred source has stayed healthy for a further
+// stale window, so a source that only sends the odd tick cannot flap the
+// symbol back and forth. Every switch is raised as an alert, except a
+// move up from the source that happened to deliver first at startup,
+// since nothing had failed over.
+type Merge struct {
+	sourceBase
+	sources []Source
+	names   []string
+	order   map[string][]int // symbol -> source indexes, best first
+	stale   time.Duration
+	alerts  chan types.Alert
+
+	// Owned by the loop goroutine. freshSince is when each source last
+	// became healthy for the symbol after a gap or a disconnect; initial
+	// marks symbols still on the source their first election chose.
+	lastSeen   map[string][]time.Time
+	freshSince map[string][]time.Time
+	active     map[string]int
+	initial    map[string]bool
+	up         []bool
+}
+
+func NewMerge(symbols []string, cfg config.MergeFeed) (*Merge, error) {
+	m := &Merge{
+		order:      make(map[string][]int, len(symbols)),
+		stale:      time.Duration(cfg.StaleMs) * time.Millisecond,
+		alerts:     make(chan types.Alert, 64),
+		lastSeen:   make(map[string][]time.Time, len(symbols)),
+		freshSince: make(map[string][]time.Time, len(symbols)),
+		active:     make(map[string]int, len(symbols)),
+		initial:    make(map[string]bool, len(symbols)),
+	}
+
+	index := make(map[string]int, len(cfg.Sources))
+	for i, sc := range cfg.Sources {
+		src, err := New(symbols, sc)
+		if err != nil {
+			// Release whatever the sources built so far hold, such as
+			// a listener's bound ports.
+			for _, built := range m.sources {
+				built.Stop()
+			}
+			return nil, fmt.Errorf("merge source %s: %w", sc.Name, err)
+		}
+		m.sources = append(m.sources, src)
+		m.names = append(m.names, sc.Name)
+		index[sc.Name] = i
+	}
+	m.up = make([]bool, len(m.sources))
+
+	for _, sym := range symbols {
+		var order []int
+		if names, ok := cfg.Priority[sym]; ok {
+			for _, name := range names {
+				order = append(order, index[name])
+			}
+		} else {
+			for i := range m.sources {
+				order = append(order, i)
+			}
+		}
+		m.order[sym] = order
+		m.lastSeen[sym] = make([]time.Time, len(m.sources))
+		m.freshSince[sym] = make([]time.Time, len(m.sources))
+		m.active[sym] = noSource
+	}
+
+	return m, nil
+}
+
+func (m *Merge) Name() string { return "merge" }
+
+// Alerts delivers failover events. Events are dropped if nobody reads.
+func (m *Merge) Alerts() <-chan types.Alert { return m.alerts }
+
+// Health reports the merge as connected while any source is.
+func (m *Merge) Health() Health {
+	h := m.sourceBase.Health()
+	h.Connected = false
+	for _, src := range m.sources {
+		if src.Health().Connected {
+			h.Connected = true
+		}
+	}
+	return h
+}
+
+// noSource marks a symbol that has not had an active source yet; -1
+// marks one whose sources have all gone stale.
+const noSource = -2
+
+type sourcedTick struct {
+	src  int
+	tick Tick
+}
+
+func (m *Merge) Start(ctx context.Context) <-chan Tick {
+	ctx = m.run(ctx)
+	out := make(chan Tick, 1024)
+	in := make(chan sourcedTick, 1024)
+
+	var wg sync.WaitGroup
+	for i, src := range m.sources {
+		wg.Add(1)
+		go func(i int, ch <-chan Tick) {
+			defer wg.Done()
+			for tick := range ch {
+				select {
+				case in <- sourcedTick{i, tick}:
+				case <-ctx.Done():
+				}
+			}
+		}(i, src.Start(ctx))
+	}
+	go func() {
+		wg.Wait()
+		close(in)
+	}()
+
+	go func() {
+		defer close(out)
+		defer close(m.alerts)
+		defer m.setConnected(false)
+
+		check := time.NewTicker(m.stale / 4)
+		defer check.Stop()
+
+		for {
+			select {
+			case st, ok := <-in:
+				if !ok {
+					return
+				}
+				if !m.accept(st) {
+					continue
+				}
+				select {
+				case out <- st.tick:
+					m.recordTick(st.tick.Time)
+				case <-ctx.Done():
+				}
+			case now := <-check.C:
+				for i, src := range m.sources {
+					m.up[i] = src.Health().Connected
+				}
+				for sym := range m.order {
+					m.elect(sym, now)
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// accept records the tick's arrival and reports whether it comes from the
+// symbol's active source.
+func (m *Merge) accept(st sourcedTick) bool {
+	seen, ok := m.lastSeen[st.tick.Symbol]
+	if !ok {
+		return false
+	}
+	now := time.Now()
+	if !m.up[st.src] || now.Sub(seen[st.src]) >= m.stale {
+		m.freshSince[st.tick.Symbol][st.src] = now
+	}
+	seen[st.src] = now
+	m.up[st.src] = true
+
+	return m.elect(st.tick.Symbol, now) == st.src
+}
+
+// elect picks the best healthy source for sym, raising an alert when that
+// changes the active source. While the active source is healthy, one
+// ranked above it takes over only once it has been healthy for a stale
+// window itself.
+func (m *Merge) elect(sym string, now time.Time) int {
+	prev := m.active[sym]
+	holding := prev >= 0 && m.healthy(sym, prev, now)
+
+	best := -1
+	for _, i := range m.order[sym] {
+		if !m.healthy(sym, i, now) {
+			continue
+		}
+		if holding && i != prev && now.Sub(m.freshSince[sym][i]) < m.stale {
+			continue
+		}
+		best = i
+		break
+	}
+
+	if best == prev || (prev == noSource && best < 0) {
+		return best
+	}
+	m.active[sym] = best
+	initial := m.initial[sym]
+	m.initial[sym] = prev == noSource
+
+	switch {
+	case prev == noSource:
+		log.Printf("merge: %s served by %s", sym, m.names[best])
+	case initial && best >= 0 && m.rank(sym, best) < m.rank(sym, prev):
+		// The startup election took whichever source delivered first.
+		log.Printf("merge: %s served by %s", sym, m.names[best])
+	case best < 0:
+		m.raise(types.Alert{
+			Level:     "CRITICAL",
+			Symbol:    sym,
+			Message:   fmt.Sprintf("no healthy feed (%s went stale)", m.names[prev]),
+			Value:     now.Sub(m.lastSeen[sym][prev]).Seconds(),
+			Threshold: m.stale.Seconds(),
+			Time:      now,
+		})
+	case prev < 0:
+		m.raise(types.Alert{
+			Level:   "INFO",
+			Symbol:  sym,
+			Message: fmt.Sprintf("feed restored via %s", m.names[best]),
+			Time:    now,
+		})
+	case m.rank(sym, best) < m.rank(sym, prev):
+		m.raise(types.Alert{
+			Level:   "INFO",
+			Symbol:  sym,
+			Message: fmt.Sprintf("feed failback %s -> %s", m.names[prev], m.names[best]),
+			Time:    now,
+		})
+	default:
+		m.raise(types.Alert{
+			Level:     "HIGH",
+			Symbol:    sym,
+			Message:   fmt.Sprintf("feed failover %s -> %s", m.names[prev], m.names[best]),
+			Value:     now.Sub(m.lastSeen[sym][prev]).Seconds(),
+			Threshold: m.stale.Seconds(),
+			Time:      now,
+		})
+	}
+	return best
+}
+
+func (m *Merge) healthy(sym string, src int, now time.Time) bool {
+	return m.up[src] && now.Sub(m.lastSeen[sym][src]) < m.stale
+}
+
+func (m *Merge) rank(sym string, src int) int {
+	for r, i := range m.order[sym] {
+		if i == src {
+			return r
+		}
+	}
+	return len(m.order[sym])
+}
+
+func (m *Merge) raise(a types.Alert) {
+	log.Printf("merge: %s %s", a.Symbol, a.Message)
+	select {
+	case m.alerts <- a:
+	default:
+	}
+}
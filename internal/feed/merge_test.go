@@ -0,0 +1,247 @@
+package feed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+// stubSource is a source whose ticks the test sends by hand.
+type stubSource struct {
+	sourceBase
+	ticks chan Tick
+}
+
+var stubs = map[string]*stubSource{}
+
+func init() {
+	Register("stub", func(symbols []string, cfg config.Feed) (Source, error) {
+		s := &stubSource{ticks: make(chan Tick, 64)}
+		stubs[cfg.Name] = s
+		return s, nil
+	})
+}
+
+func (s *stubSource) Name() string { return "stub" }
+
+func (s *stubSource) Start(ctx context.Context) <-chan Tick {
+	s.run(ctx)
+	return s.ticks
+}
+
+func (s *stubSource) send(price float64) {
+	s.ticks <- Tick{Symbol: "AAPL", Price: price, Time: time.Now()}
+}
+
+// TestMergeFailover walks one symbol through failover to the backup,
+// failback to the primary once it has stayed fresh, losing both feeds
+// and getting one back, and checks which ticks pass and that each switch
+// raises one alert.
+func TestMergeFailover(t *testing.T) {
+	m, err := NewMerge([]string{"AAPL"}, config.MergeFeed{
+		Sources: []config.Feed{{Type: "stub", Name: "primary"}, {Type: "stub", Name: "backup"}},
+		StaleMs: 200,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary, backup := stubs["primary"], stubs["backup"]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := m.Start(ctx)
+
+	// awaitAlert keeps ticks feeding from the given sources, at the given
+	// prices, until an alert arrives.
+	type feeding struct {
+		src   *stubSource
+		price float64
+	}
+	awaitAlert := func(feeds ...feeding) types.Alert {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		for {
+			select {
+			case a := <-m.Alerts():
+				return a
+			case <-time.After(20 * time.Millisecond):
+				for _, f := range feeds {
+					f.src.send(f.price)
+				}
+			case <-deadline:
+				t.Fatal("timed out waiting for a merge alert")
+			}
+		}
+	}
+	// next skips the backup's ticks up to the given price.
+	next := func(price float64) {
+		t.Helper()
+		for {
+			got := recvTick(t, out).Price
+			if got == price {
+				return
+			}
+			if got < 200 {
+				t.Fatalf("got %v from the primary, want %v", got, price)
+			}
+		}
+	}
+
+	// The primary is preferred while both are fresh: the backup's tick
+	// in between is held back.
+	primary.send(100)
+	if got := recvTick(t, out).Price; got != 100 {
+		t.Fatalf("first tick %v, want 100", got)
+	}
+	backup.send(200)
+	primary.send(101)
+	if got := recvTick(t, out).Price; got != 101 {
+		t.Fatalf("tick %v while the primary is active, want 101", got)
+	}
+
+	var got []types.Alert
+	got = append(got, awaitAlert(feeding{backup, 201}))
+	backup.send(250)
+	next(250)
+
+	// The primary's ticks are held back until it has been fresh for a
+	// stale window.
+	got = append(got, awaitAlert(feeding{primary, 102}, feeding{backup, 260}))
+	next(102)
+	backup.send(270)
+	primary.send(103)
+	tick := recvTick(t, out).Price
+	for tick == 102 {
+		tick = recvTick(t, out).Price
+	}
+	if tick != 103 {
+		t.Fatalf("tick %v after failback, want 103 from the primary", tick)
+	}
+
+	got = append(got, awaitAlert())
+	backup.send(280)
+	got = append(got, awaitAlert())
+	next(280)
+
+	want := []struct{ level, msg string }{
+		{"HIGH", "feed failover primary -> backup"},
+		{"INFO", "feed failback backup -> primary"},
+		{"CRITICAL", "no healthy feed (primary went stale)"},
+		{"INFO", "feed restored via backup"},
+	}
+	for k, w := range want {
+		if got[k].Level != w.level || got[k].Message != w.msg || got[k].Symbol != "AAPL" {
+			t.Errorf("alert %d = %s %q for %s, want %s %q", k, got[k].Level, got[k].Message, got[k].Symbol, w.level, w.msg)
+		}
+	}
+	select {
+	case a := <-m.Alerts():
+		t.Errorf("extra alert %+v", a)
+	default:
+	}
+}
+
+// TestMergeFailbackHoldDown keeps the backup ticking while the primary
+// only sends a tick now and then, each one going stale before the next.
+// The symbol must stay on the backup: no failback, no repeated failover
+// alerts and none of the primary's ticks forwarded.
+func TestMergeFailbackHoldDown(t *testing.T) {
+	m, err := NewMerge([]string{"AAPL"}, config.MergeFeed{
+		Sources: []config.Feed{{Type: "stub", Name: "sparse"}, {Type: "stub", Name: "steady"}},
+		StaleMs: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary, backup := stubs["sparse"], stubs["steady"]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := m.Start(ctx)
+
+	primary.send(100)
+	if got := recvTick(t, out).Price; got != 100 {
+		t.Fatalf("first tick %v, want 100", got)
+	}
+
+	var alerts []types.Alert
+	var forwarded []float64
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	for k := 1; k <= 120; k++ {
+		<-tick.C
+		backup.send(200)
+		if k%15 == 0 {
+			primary.send(100)
+		}
+	drain:
+		for {
+			select {
+			case a := <-m.Alerts():
+				alerts = append(alerts, a)
+			case tk := <-out:
+				forwarded = append(forwarded, tk.Price)
+			default:
+				break drain
+			}
+		}
+	}
+
+	if len(alerts) != 1 || alerts[0].Message != "feed failover sparse -> steady" {
+		t.Errorf("alerts = %+v, want a single failover to steady", alerts)
+	}
+	onBackup := false
+	for _, p := range forwarded {
+		if p == 200 {
+			onBackup = true
+		} else if onBackup {
+			t.Fatalf("sparse primary's tick forwarded after failover: %v", forwarded)
+		}
+	}
+}
+
+// TestMergeStartupRace has the backup deliver first, so the startup
+// election picks it. When the primary then takes the symbol over, that
+// is no failback: nothing failed over, so no alert may fire.
+func TestMergeStartupRace(t *testing.T) {
+	m, err := NewMerge([]string{"AAPL"}, config.MergeFeed{
+		Sources: []config.Feed{{Type: "stub", Name: "late"}, {Type: "stub", Name: "early"}},
+		StaleMs: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary, backup := stubs["late"], stubs["early"]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := m.Start(ctx)
+
+	backup.send(200)
+	if got := recvTick(t, out).Price; got != 200 {
+		t.Fatalf("first tick %v, want 200 from the backup", got)
+	}
+
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	onPrimary := false
+	for k := 0; k < 40; k++ {
+		<-tick.C
+		backup.send(200)
+		primary.send(100)
+	drain:
+		for {
+			select {
+			case a := <-m.Alerts():
+				t.Fatalf("alert %s %q at startup", a.Level, a.Message)
+			case tk := <-out:
+				onPrimary = onPrimary || tk.Price == 100
+			default:
+				break drain
+			}
+		}
+	}
+	if !onPrimary {
+		t.Error("the primary never took the symbol over")
+	}
+}
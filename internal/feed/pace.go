@@ -0,0 +1,48 @@
+package feed
+
+import (
+	"context"
+	"time"
+)
+
+// pacer spaces out replayed ticks according to their recorded times.
+// A speed of 1 reproduces the original timing, N plays back N times
+// faster and 0 disables pacing altogether.
+type pacer struct {
+	speed float64
+	first time.Time
+	start time.Time
+}
+
+func newPacer(speed float64) *pacer {
+	return &pacer{speed: speed}
+}
+
+// wait blocks until a tick recorded at t is due, or ctx is cancelled.
+func (p *pacer) wait(ctx context.Context, t time.Time) error {
+	if p.speed <= 0 {
+		return ctx.Err()
+	}
+
+	if p.start.IsZero() {
+		p.first = t
+		p.start = time.Now()
+		return ctx.Err()
+	}
+
+	due := p.start.Add(time.Duration(float64(t.Sub(p.first)) / p.speed))
+	d := time.Until(due)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+package feed
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"matrixpulse/internal/config"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// scenario walks the gbm simulator through a script of timed phases. Each
+// phase can override the correlation, uniformly or with a full matrix,
+// scale volatility, add Poisson jumps and fire scheduled jumps on named
+// symbols.
+type scenario struct {
+	phases []phase
+	loop   bool
+
+	idx   int
+	start time.Time
+	fired []bool
+}
+
+type phase struct {
+	name     string
+	duration time.Duration
+	chol     *mat.TriDense // nil keeps the base correlation
+	volMult  float64
+	jumpRate float64 // jumps per symbol per second
+	jumpMean float64
+	jumpStd  float64
+	jumps    []scheduledJump
+}
+
+type scheduledJump struct {
+	symbol int
+	at     time.Duration
+	logRet float64
+}
+
+func newScenario(cfg config.Scenario, symbols []string) (*scenario, error) {
+	if len(cfg.Phases) == 0 {
+		return nil, nil
+	}
+
+	index := make(map[string]int, len(symbols))
+	for i, sym := range symbols {
+		index[sym] = i
+	}
+
+	sc := &scenario{loop: cfg.Loop, idx: -1}
+	for _, pc := range cfg.Phases {
+		p := phase{
+			name:     pc.Name,
+			duration: time.Duration(pc.DurationSec * float64(time.Second)),
+			volMult:  pc.VolMultiplier,
+			jumpRate: pc.JumpIntensity,
+			jumpMean: pc.JumpMean,
+			jumpStd:  pc.JumpStd,
+		}
+		if p.volMult == 0 {
+			p.volMult = 1
+		}
+
+		cor := pc.CorrelationMatrix
+		if pc.Correlation != nil {
+			cor = uniformCorrelation(len(symbols), *pc.Correlation)
+		}
+		if cor != nil {
+			chol, err := cholesky(cor, len(symbols))
+			if err != nil {
+				return nil, fmt.Errorf("scenario phase %q: %w", pc.Name, err)
+			}
+			p.chol = chol
+		}
+
+		for _, j := range pc.Jumps {
+			i, ok := index[j.Symbol]
+			if !ok {
+				return nil, fmt.Errorf("scenario phase %q: jump on unknown symbol %s", pc.Name, j.Symbol)
+			}
+			p.jumps = append(p.jumps, scheduledJump{
+				symbol: i,
+				at:     time.Duration(j.AtSec * float64(time.Second)),
+				logRet: math.Log1p(j.Size),
+			})
+		}
+
+		sc.phases = append(sc.phases, p)
+	}
+
+	return sc, nil
+}
+
+// uniformCorrelation builds an n×n matrix with rho off the diagonal.
+func uniformCorrelation(n int, rho float64) [][]float64 {
+	cor := make([][]float64, n)
+	for i := range cor {
+		cor[i] = make([]float64, n)
+		for j := range cor[i] {
+			if i == j {
+				cor[i][j] = 1
+			} else {
+				cor[i][j] = rho
+			}
+		}
+	}
+	return cor
+}
+
+// at returns the phase active at t, or nil once a non-looping script has
+// run out, and reports the time elapsed within that phase.
+func (sc *scenario) at(t time.Time) (*phase, time.Duration) {
+	if sc.idx < 0 {
+		sc.enter(0, t)
+	}
+
+	for sc.idx < len(sc.phases) && t.Sub(sc.start) >= sc.phases[sc.idx].duration {
+		next := sc.idx + 1
+		if next == len(sc.phases) && sc.loop {
+			next = 0
+		}
+		sc.enter(next, sc.start.Add(sc.phases[sc.idx].duration))
+	}
+
+	if sc.idx >= len(sc.phases) {
+		return nil, 0
+	}
+	return &sc.phases[sc.idx], t.Sub(sc.start)
+}
+
+func (sc *scenario) enter(idx int, t time.Time) {
+	sc.idx = idx
+	sc.start = t
+
+	if idx >= len(sc.phases) {
+		log.Printf("simulated: scenario finished, resuming base model")
+		return
+	}
+	sc.fired = make([]bool, len(sc.phases[idx].jumps))
+	log.Printf("simulated: entering scenario phase %q", sc.phases[idx].name)
+}
+
+// due returns the log-return of scheduled jumps that have come due in
+// the current phase and not yet fired, for the given symbol index.
+func (sc *scenario) due(p *phase, elapsed time.Duration, symbol int) float64 {
+	total := 0.0
+	for k, j := range p.jumps {
+		if j.symbol == symbol && !sc.fired[k] && elapsed >= j.at {
+			sc.fired[k] = true
+			total += j.logRet
+		}
+	}
+	return total
+}
@@ -0,0 +1,139 @@
+package feed
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/engine"
+)
+
+func TestScenarioSchedule(t *testing.T) {
+	phases := []config.ScenarioPhase{
+		{Name: "calm", DurationSec: 5},
+		{Name: "crash", DurationSec: 3},
+	}
+	t0 := time.Unix(1700000000, 0)
+	for _, tc := range []struct {
+		loop    bool
+		at      time.Duration
+		phase   string // "" once the script has run out
+		elapsed time.Duration
+	}{
+		{false, 0, "calm", 0},
+		{false, 4900 * time.Millisecond, "calm", 4900 * time.Millisecond},
+		{false, 5 * time.Second, "crash", 0},
+		{false, 7 * time.Second, "crash", 2 * time.Second},
+		{false, 8 * time.Second, "", 0},
+		{false, time.Hour, "", 0},
+		{true, 8 * time.Second, "calm", 0},
+		{true, 13500 * time.Millisecond, "crash", 500 * time.Millisecond},
+		// A long gap runs through whole cycles: 100 s is 12 cycles and 4 s.
+		{true, 100 * time.Second, "calm", 4 * time.Second},
+	} {
+		sc, err := newScenario(config.Scenario{Loop: tc.loop, Phases: phases}, []string{"A"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc.at(t0)
+		p, elapsed := sc.at(t0.Add(tc.at))
+		name := ""
+		if p != nil {
+			name = p.name
+		}
+		if name != tc.phase || elapsed != tc.elapsed {
+			t.Errorf("loop %v at %v: phase %q after %v, want %q after %v", tc.loop, tc.at, name, elapsed, tc.phase, tc.elapsed)
+		}
+	}
+}
+
+// TestScenarioJumps runs a looping script on flat prices so only the
+// scheduled jump moves B: 50% two seconds into each 5 s shock phase.
+func TestScenarioJumps(t *testing.T) {
+	flat := config.SimulatedAsset{Price: 100}
+	cfg := config.SimulatedFeed{
+		IntervalMs: 1000,
+		Mode:       "gbm",
+		Seed:       3,
+		Clock:      "virtual",
+		Assets:     map[string]config.SimulatedAsset{"A": flat, "B": flat},
+		Scenario: config.Scenario{Loop: true, Phases: []config.ScenarioPhase{
+			{Name: "shock", DurationSec: 5, Jumps: []config.ScheduledJump{{Symbol: "B", AtSec: 2, Size: 0.5}}},
+			{Name: "calm", DurationSec: 5},
+		}},
+	}
+	ticks := collect(t, []string{"A", "B"}, cfg, 30)
+
+	for k := 0; k < 15; k++ {
+		a, b := ticks[2*k], ticks[2*k+1]
+		want := 100.0
+		switch {
+		case k >= 12:
+			want = 225
+		case k >= 2:
+			want = 150
+		}
+		if a.Price != 100 || math.Abs(b.Price-want) > 1e-9 {
+			t.Errorf("second %d: A %v, B %v, want 100 and %v", k, a.Price, b.Price, want)
+		}
+	}
+
+	cfg.Scenario.Phases[0].Jumps[0].Symbol = "C"
+	if _, err := NewSimulated([]string{"A", "B"}, cfg); err == nil || !strings.Contains(err.Error(), "unknown symbol C") {
+		t.Errorf("jump on an untracked symbol: error %v", err)
+	}
+}
+
+// TestScenarioRegimes drives six symbols through the engine with a
+// script that should read NORMAL, then STRESSED, then CRISIS. Uniform
+// correlation cannot give STRESSED here: by the time the condition
+// number passes 50 the top eigenvalue is past the crisis threshold. A
+// near-duplicate pair in an otherwise loose matrix can, since it adds a
+// tiny eigenvalue without a large one.
+func TestScenarioRegimes(t *testing.T) {
+	symbols := []string{"A", "B", "C", "D", "E", "F"}
+	n := len(symbols)
+	loose, tight := 0.1, 0.7
+	pair := uniformCorrelation(n, 0)
+	pair[0][1], pair[1][0] = 0.99, 0.99
+
+	const phaseSec = 200
+	src, err := NewSimulated(symbols, config.SimulatedFeed{
+		IntervalMs: 1000,
+		Mode:       "gbm",
+		Seed:       11,
+		Clock:      "virtual",
+		Scenario: config.Scenario{Phases: []config.ScenarioPhase{
+			{Name: "normal", DurationSec: phaseSec, Correlation: &loose},
+			{Name: "stressed", DurationSec: phaseSec, CorrelationMatrix: pair},
+			{Name: "crisis", DurationSec: phaseSec, Correlation: &tight},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eng := engine.New(symbols, 120, config.Alerts{Eigenvalue: 2.8}, config.Engine{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ticks := src.Start(ctx)
+
+	for _, want := range []string{"NORMAL", "STRESSED", "CRISIS"} {
+		// Stop just short of the phase's end so the window holds its
+		// returns only.
+		for i := 0; i < (phaseSec-2)*n; i++ {
+			eng.Ingest(<-ticks)
+		}
+		eng.Compute()
+		mode := eng.Mode()
+		if mode == nil || mode.Regime != want {
+			t.Fatalf("regime %+v, want %s", mode, want)
+		}
+		for i := 0; i < 2*n; i++ {
+			eng.Ingest(<-ticks)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/record"
+)
+
+func init() {
+	Register("session", func(symbols []string, cfg config.Feed) (Source, error) {
+		return NewSession(cfg.Session)
+	})
+}
+
+// Session replays files written by record.Recorder with their original
+// inter-arrival timing, scaled by the configured speed. Files are played
+// in name order, which for recorder output is chronological.
+type Session struct {
+	sourceBase
+	files []string
+	speed float64
+}
+
+func NewSession(cfg config.SessionFeed) (*Session, error) {
+	var files []string
+	for _, pattern := range cfg.Files {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad session file pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no session files match %q", pattern)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	return &Session{files: files, speed: cfg.Speed}, nil
+}
+
+func (s *Session) Name() string { return "session" }
+
+func (s *Session) Start(ctx context.Context) <-chan Tick {
+	ctx = s.run(ctx)
+	out := make(chan Tick, 256)
+
+	go func() {
+		defer close(out)
+		defer s.setConnected(false)
+
+		p := newPacer(s.speed)
+		for _, path := range s.files {
+			if err := s.replay(ctx, path, p, out); err != nil {
+				if ctx.Err() == nil {
+					s.recordError(err)
+					log.Printf("session replay stopped: %v", err)
+				}
+				return
+			}
+		}
+		log.Printf("session replay finished (%d ticks)", s.Health().Ticks)
+	}()
+
+	return out
+}
+
+func (s *Session) replay(ctx context.Context, path string, p *pacer, out chan<- Tick) error {
+	r, err := record.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		tick, arrived, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			log.Printf("session: %s ends with a truncated record", path)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := p.wait(ctx, arrived); err != nil {
+			return err
+		}
+
+		select {
+		case out <- tick:
+			s.recordTick(tick.Time)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
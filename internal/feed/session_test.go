@@ -0,0 +1,53 @@
+package feed
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/record"
+)
+
+// TestSessionPacesOnArrival replays ticks whose vendor timestamps are a
+// minute apart but which arrived 20 ms apart, as a 10x CSV replay
+// would record them. Replay must follow the arrival timing and still
+// hand over the original timestamps.
+func TestSessionPacesOnArrival(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := record.New(config.Recording{Dir: dir, MaxMB: 1, MaxMinutes: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC)
+	arrived := time.Now()
+	for k := 0; k < 6; k++ {
+		tick := Tick{Symbol: "AAPL", Price: 100 + float64(k), Time: t0.Add(time.Duration(k) * time.Minute)}
+		if err := rec.Write(tick, arrived.Add(time.Duration(k)*20*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSession(config.SessionFeed{Files: []string{filepath.Join(dir, "*"+record.Ext)}, Speed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	ticks := s.Start(ctx)
+	for k := 0; k < 6; k++ {
+		tick := recvTick(t, ticks)
+		if want := t0.Add(time.Duration(k) * time.Minute); !tick.Time.Equal(want) || tick.Price != 100+float64(k) {
+			t.Errorf("tick %d = %v at %v, want %v at %v", k, tick.Price, tick.Time, 100+float64(k), want)
+		}
+	}
+	if took := time.Since(start); took < 100*time.Millisecond || took > 2*time.Second {
+		t.Errorf("replay took %v, want about 100ms of recorded arrival spacing", took)
+	}
+}
@@ -2,60 +2,265 @@ package feed
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"math"
+	"math/rand"
 	"time"
 
+	"matrixpulse/internal/clock"
+	"matrixpulse/internal/config"
 	"matrixpulse/internal/types"
+
+	"gonum.org/v1/gonum/mat"
 )
 
 type Tick = types.Tick
 
+// virtualEpoch is where a virtual clock starts when no start time is set,
+// so that seeded runs are reproducible down to their timestamps.
+var virtualEpoch = time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC)
+
+func init() {
+	Register("simulated", func(symbols []string, cfg config.Feed) (Source, error) {
+		return NewSimulated(symbols, cfg.Simulated)
+	})
+}
+
+// Simulated generates synthetic prices. In "sine" mode each symbol follows
+// phase-shifted sine waves plus noise; in "gbm" mode all symbols follow
+// geometric Brownian motion with returns correlated according to the
+// configured correlation matrix.
+//
+// Every symbol is stepped from one goroutine using a single seeded random
+// source, so a given seed and clock produce an identical tick sequence.
 type Simulated struct {
-	symbols []string
+	sourceBase
+	symbols  []string
+	interval time.Duration
+	mode     string
+	seed     int64
+	clock    clock.Clock
+	rng      *rand.Rand
+
+	// gbm mode
+	chol     *mat.TriDense
+	prices   []float64
+	drift    []float64
+	vol      []float64
+	scenario *scenario
+	z, e     *mat.VecDense
 }
 
-func NewSimulated(symbols []string) *Simulated {
-	return &Simulated{symbols: symbols}
+func NewSimulated(symbols []string, cfg config.SimulatedFeed) (*Simulated, error) {
+	s := &Simulated{
+		symbols:  symbols,
+		interval: time.Duration(cfg.IntervalMs) * time.Millisecond,
+		mode:     cfg.Mode,
+		seed:     cfg.Seed,
+		clock:    clock.Real{},
+	}
+	if s.seed == 0 {
+		s.seed = time.Now().UnixNano()
+	}
+	if cfg.Clock == "virtual" {
+		start := cfg.StartTime
+		if start.IsZero() {
+			start = virtualEpoch
+		}
+		s.clock = clock.NewVirtual(start)
+	}
+
+	if s.mode != "gbm" {
+		return s, nil
+	}
+
+	n := len(symbols)
+	s.prices = make([]float64, n)
+	s.drift = make([]float64, n)
+	s.vol = make([]float64, n)
+	for i, sym := range symbols {
+		a, ok := cfg.Assets[sym]
+		if !ok {
+			a = config.SimulatedAsset{Price: 100, Volatility: 0.2}
+		}
+		s.prices[i] = a.Price
+		s.drift[i] = a.Drift
+		s.vol[i] = a.Volatility
+	}
+	s.z = mat.NewVecDense(n, nil)
+	s.e = mat.NewVecDense(n, nil)
+
+	chol, err := cholesky(cfg.Correlation, n)
+	if err != nil {
+		return nil, err
+	}
+	s.chol = chol
+
+	if s.scenario, err = newScenario(cfg.Scenario, symbols); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// cholesky returns the lower Cholesky factor of the target correlation
+// matrix, or of the identity when none is given.
+func cholesky(cor [][]float64, n int) (*mat.TriDense, error) {
+	sym := mat.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		sym.SetSym(i, i, 1)
+	}
+	if len(cor) > 0 {
+		if len(cor) != n {
+			return nil, fmt.Errorf("correlation matrix has %d rows, want %d", len(cor), n)
+		}
+		for i := 0; i < n; i++ {
+			if len(cor[i]) != n {
+				return nil, fmt.Errorf("correlation row %d has %d entries, want %d", i, len(cor[i]), n)
+			}
+			for j := 0; j < i; j++ {
+				if cor[i][j] != cor[j][i] {
+					return nil, fmt.Errorf("correlation matrix is not symmetric at (%d,%d)", i, j)
+				}
+				sym.SetSym(i, j, cor[i][j])
+			}
+		}
+	}
+
+	var c mat.Cholesky
+	if !c.Factorize(sym) {
+		return nil, fmt.Errorf("correlation matrix is not positive definite")
+	}
+	var l mat.TriDense
+	c.LTo(&l)
+	return &l, nil
 }
 
+// SetClock replaces the clock driving the simulator. It must be called
+// before Start.
+func (s *Simulated) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+func (s *Simulated) Name() string { return "simulated" }
+
 func (s *Simulated) Start(ctx context.Context) <-chan Tick {
+	ctx = s.run(ctx)
 	out := make(chan Tick, len(s.symbols)*20)
 
-	for idx, sym := range s.symbols {
-		go func(symbol string, offset int) {
-			base := 100.0 + float64(len(symbol)*10)
-			ticker := time.NewTicker(25 * time.Millisecond)
-			defer ticker.Stop()
-
-			phase := float64(offset) * 0.5
-
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case t := <-ticker.C:
-					ts := float64(t.UnixNano()) / 1e9
-
-					drift := math.Sin(ts/5.0+phase) * 1.5
-					vol := 0.3 + 0.2*math.Sin(ts/20.0)
-					noise := (float64(t.UnixNano()%10000)/5000.0 - 1.0) * vol
-					trend := math.Sin(ts/30.0+phase) * 0.5
-
-					price := base + drift + noise + trend
-					if price < 1 {
-						price = 1
-					}
-
-					out <- Tick{
-						Symbol: symbol,
-						Price:  price,
-						Volume: 1000 + float64(t.UnixNano()%5000),
-						Time:   t,
-					}
-				}
+	log.Printf("simulated: %s mode, seed %d", s.mode, s.seed)
+	s.rng = rand.New(rand.NewSource(s.seed))
+
+	go func() {
+		defer close(out)
+		defer s.setConnected(false)
+
+		next := s.clock.Now()
+		for {
+			next = next.Add(s.interval)
+			if err := s.clock.SleepUntil(ctx, next); err != nil {
+				return
 			}
-		}(sym, idx)
-	}
+
+			var ok bool
+			if s.mode == "gbm" {
+				ok = s.stepGBM(ctx, out, next)
+			} else {
+				ok = s.stepSine(ctx, out, next)
+			}
+			if !ok {
+				return
+			}
+		}
+	}()
 
 	return out
 }
+
+func (s *Simulated) stepSine(ctx context.Context, out chan<- Tick, t time.Time) bool {
+	ts := float64(t.UnixNano()) / 1e9
+
+	for idx, symbol := range s.symbols {
+		base := 100.0 + float64(len(symbol)*10)
+		phase := float64(idx) * 0.5
+
+		drift := math.Sin(ts/5.0+phase) * 1.5
+		vol := 0.3 + 0.2*math.Sin(ts/20.0)
+		noise := (s.rng.Float64()*2 - 1.0) * vol
+		trend := math.Sin(ts/30.0+phase) * 0.5
+
+		price := base + drift + noise + trend
+		if price < 1 {
+			price = 1
+		}
+
+		if !s.emit(ctx, out, Tick{
+			Symbol: symbol,
+			Price:  price,
+			Volume: 1000 + s.rng.Float64()*5000,
+			Time:   t,
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// stepGBM moves every symbol together so their shocks can be correlated:
+// independent normals are mixed through the Cholesky factor before being
+// applied as log-price increments. An active scenario phase may swap the
+// factor, scale volatility and add jumps.
+func (s *Simulated) stepGBM(ctx context.Context, out chan<- Tick, t time.Time) bool {
+	n := len(s.symbols)
+	dt := float64(s.interval) / float64(clock.TradingYear)
+
+	chol, volMult := s.chol, 1.0
+	var p *phase
+	var elapsed time.Duration
+	if s.scenario != nil {
+		if p, elapsed = s.scenario.at(t); p != nil {
+			volMult = p.volMult
+			if p.chol != nil {
+				chol = p.chol
+			}
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		s.z.SetVec(i, s.rng.NormFloat64())
+	}
+	s.e.MulVec(chol, s.z)
+
+	for i, sym := range s.symbols {
+		sigma := s.vol[i] * volMult
+		logRet := (s.drift[i]-0.5*sigma*sigma)*dt + sigma*math.Sqrt(dt)*s.e.AtVec(i)
+
+		if p != nil {
+			logRet += s.scenario.due(p, elapsed, i)
+			if p.jumpRate > 0 && s.rng.Float64() < 1-math.Exp(-p.jumpRate*s.interval.Seconds()) {
+				logRet += p.jumpMean + p.jumpStd*s.rng.NormFloat64()
+			}
+		}
+		s.prices[i] *= math.Exp(logRet)
+
+		if !s.emit(ctx, out, Tick{
+			Symbol: sym,
+			Price:  s.prices[i],
+			Volume: 1000 + s.rng.Float64()*5000,
+			Time:   t,
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Simulated) emit(ctx context.Context, out chan<- Tick, t Tick) bool {
+	select {
+	case out <- t:
+		s.recordTick(t.Time)
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
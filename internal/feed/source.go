@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+// Source is a market data feed. Start begins delivery on the returned
+// channel, which is closed once the source has shut down; Stop (or
+// cancelling the context passed to Start) requests that shutdown.
+type Source interface {
+	Start(ctx context.Context) <-chan Tick
+	Stop()
+	Name() string
+	Health() Health
+}
+
+// Alerter is implemented by sources that raise alerts of their own, such
+// as failover events. The channel is closed when the source stops.
+type Alerter interface {
+	Alerts() <-chan types.Alert
+}
+
+// Health is a point-in-time view of a source's condition.
+type Health struct {
+	Connected bool
+	Ticks     uint64
+	Errors    uint64
+	LastTick  time.Time
+	LastError string
+}
+
+// Factory builds a source for the given symbols from its config section.
+type Factory func(symbols []string, cfg config.Feed) (Source, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a source type available to New under name. It is meant
+// to be called from init and panics on duplicate registration.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, dup := registry[name]; dup {
+		panic("feed: duplicate registration of " + name)
+	}
+	registry[name] = f
+}
+
+// New builds the source named by cfg.Type.
+func New(symbols []string, cfg config.Feed) (Source, error) {
+	registryMu.RLock()
+	f, ok := registry[cfg.Type]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown feed type %q (available: %v)", cfg.Type, Types())
+	}
+	return f(symbols, cfg)
+}
+
+// Types lists the registered source types in sorted order.
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sourceBase carries the lifecycle and health bookkeeping shared by sources.
+// Embedding it provides the Stop and Health methods of Source.
+type sourceBase struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	health Health
+}
+
+// run derives the context a source's goroutines should watch.
+func (b *sourceBase) run(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	b.mu.Lock()
+	b.cancel = cancel
+	b.health.Connected = true
+	b.mu.Unlock()
+	return ctx
+}
+
+func (b *sourceBase) Stop() {
+	b.mu.Lock()
+	cancel := b.cancel
+	b.health.Connected = false
+	b.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (b *sourceBase) Health() Health {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.health
+}
+
+func (b *sourceBase) recordTick(t time.Time) {
+	b.mu.Lock()
+	b.health.Ticks++
+	b.health.LastTick = t
+	b.mu.Unlock()
+}
+
+func (b *sourceBase) recordError(err error) {
+	b.mu.Lock()
+	b.health.Errors++
+	b.health.LastError = err.Error()
+	b.mu.Unlock()
+}
+
+func (b *sourceBase) setConnected(ok bool) {
+	b.mu.Lock()
+	b.health.Connected = ok
+	b.mu.Unlock()
+}
@@ -0,0 +1,322 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"matrixpulse/internal/config"
+
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	Register("websocket", func(symbols []string, cfg config.Feed) (Source, error) {
+		return NewWebSocket(symbols, cfg.WebSocket)
+	})
+}
+
+// WebSocket consumes JSON market data from a WebSocket endpoint. On every
+// (re)connect it sends the subscribe message rendered from the configured
+// template, then maps incoming messages to ticks through dotted field
+// paths. A connection that stays silent past the idle timeout is dropped
+// and re-established with exponential backoff.
+type WebSocket struct {
+	sourceBase
+	cfg       config.WebSocketFeed
+	symbols   map[string]bool
+	subscribe []byte
+	header    http.Header
+}
+
+func NewWebSocket(symbols []string, cfg config.WebSocketFeed) (*WebSocket, error) {
+	w := &WebSocket{
+		cfg:     cfg,
+		symbols: make(map[string]bool, len(symbols)),
+		header:  make(http.Header),
+	}
+	for _, sym := range symbols {
+		w.symbols[sym] = true
+	}
+	for k, v := range cfg.Headers {
+		w.header.Set(k, v)
+	}
+
+	if cfg.Subscribe != "" {
+		tmpl, err := template.New("subscribe").Funcs(template.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).Parse(cfg.Subscribe)
+		if err != nil {
+			return nil, fmt.Errorf("bad websocket subscribe template: %w", err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, struct{ Symbols []string }{symbols}); err != nil {
+			return nil, fmt.Errorf("failed to render websocket subscribe message: %w", err)
+		}
+		w.subscribe = buf.Bytes()
+	}
+
+	return w, nil
+}
+
+func (w *WebSocket) Name() string { return "websocket" }
+
+func (w *WebSocket) Start(ctx context.Context) <-chan Tick {
+	ctx = w.run(ctx)
+	w.setConnected(false)
+	out := make(chan Tick, 256)
+
+	go func() {
+		defer close(out)
+		defer w.setConnected(false)
+
+		initial := time.Duration(w.cfg.BackoffInitialMs) * time.Millisecond
+		maxBackoff := time.Duration(w.cfg.BackoffMaxMs) * time.Millisecond
+		backoff := initial
+
+		for {
+			received, err := w.session(ctx, out)
+			if ctx.Err() != nil {
+				return
+			}
+			w.setConnected(false)
+			w.recordError(err)
+
+			if received {
+				backoff = initial
+			}
+			log.Printf("websocket: %v (reconnecting in %v)", err, backoff)
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}()
+
+	return out
+}
+
+// session runs one connection until it fails, reporting whether any
+// ticks were delivered so the caller can reset its backoff.
+func (w *WebSocket) session(ctx context.Context, out chan<- Tick) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, w.cfg.URL, w.header)
+	if err != nil {
+		return false, fmt.Errorf("dial failed: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the reader when the source is stopped.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if w.subscribe != nil {
+		if err := conn.WriteMessage(websocket.TextMessage, w.subscribe); err != nil {
+			return false, fmt.Errorf("subscribe failed: %w", err)
+		}
+	}
+	w.setConnected(true)
+	log.Printf("websocket: connected to %s", w.cfg.URL)
+
+	idle := time.Duration(w.cfg.IdleTimeoutSec) * time.Second
+	conn.SetReadDeadline(time.Now().Add(idle))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(idle))
+	})
+
+	if w.cfg.HeartbeatSec > 0 {
+		go w.heartbeat(conn, done, time.Duration(w.cfg.HeartbeatSec)*time.Second)
+	}
+
+	received := false
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return received, fmt.Errorf("read failed: %w", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(idle))
+
+		ticks, err := w.decode(msg)
+		if err != nil {
+			w.recordError(err)
+		}
+
+		for _, tick := range ticks {
+			select {
+			case out <- tick:
+				w.recordTick(tick.Time)
+				received = true
+			case <-ctx.Done():
+				return received, ctx.Err()
+			}
+		}
+	}
+}
+
+func (w *WebSocket) heartbeat(conn *websocket.Conn, done <-chan struct{}, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(every)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// decode maps one message to ticks. The message (or the array found at
+// the configured items path) may hold a single object or an array of
+// them; objects without a tracked symbol or a price are ignored, which
+// skips acknowledgements and other control traffic. An item that fails
+// to parse is dropped and reported as the error, but the ticks decoded
+// from the rest of the message are still returned.
+func (w *WebSocket) decode(msg []byte) ([]Tick, error) {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("bad websocket message: %w", err)
+	}
+
+	if w.cfg.Fields.Items != "" {
+		var ok bool
+		if doc, ok = lookupPath(doc, w.cfg.Fields.Items); !ok {
+			return nil, nil
+		}
+	}
+
+	items, ok := doc.([]interface{})
+	if !ok {
+		items = []interface{}{doc}
+	}
+
+	ticks := make([]Tick, 0, len(items))
+	var firstErr error
+	for _, item := range items {
+		tick, ok, err := w.tick(item)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if ok {
+			ticks = append(ticks, tick)
+		}
+	}
+	return ticks, firstErr
+}
+
+func (w *WebSocket) tick(item interface{}) (Tick, bool, error) {
+	f := w.cfg.Fields
+
+	v, ok := lookupPath(item, f.Symbol)
+	if !ok {
+		return Tick{}, false, nil
+	}
+	sym := scalarString(v)
+	if !w.symbols[sym] {
+		return Tick{}, false, nil
+	}
+
+	v, ok = lookupPath(item, f.Price)
+	if !ok {
+		return Tick{}, false, nil
+	}
+	price, err := strconv.ParseFloat(scalarString(v), 64)
+	if err != nil {
+		return Tick{}, false, fmt.Errorf("bad price for %s: %v", sym, v)
+	}
+
+	tick := Tick{Symbol: sym, Price: price, Time: time.Now()}
+
+	if f.Volume != "" {
+		if v, ok := lookupPath(item, f.Volume); ok {
+			if tick.Volume, err = strconv.ParseFloat(scalarString(v), 64); err != nil {
+				return Tick{}, false, fmt.Errorf("bad volume for %s: %v", sym, v)
+			}
+		}
+	}
+
+	if f.Timestamp != "" {
+		if v, ok := lookupPath(item, f.Timestamp); ok {
+			if tick.Time, err = parseTime(scalarString(v), w.cfg.TimeFormat); err != nil {
+				return Tick{}, false, err
+			}
+		}
+	}
+
+	return tick, true, nil
+}
+
+// lookupPath walks a decoded JSON document along a dotted path such as
+// "data.0.p", where numeric segments index into arrays.
+func lookupPath(doc interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			v, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			doc = v
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			doc = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// scalarString renders a JSON scalar for parsing; vendors disagree on
+// whether prices and timestamps are numbers or strings.
+func scalarString(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	default:
+		return fmt.Sprint(x)
+	}
+}
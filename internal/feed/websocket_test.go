@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsStub is a local stand-in for a vendor endpoint. It hands each
+// connection's subscribe message to the test, then sends that
+// connection's scripted messages and goes silent.
+type wsStub struct {
+	t          *testing.T
+	subscribes chan string
+	scripts    chan []string
+}
+
+func (s *wsStub) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	up := websocket.Upgrader{}
+	conn, err := up.Upgrade(rw, r, nil)
+	if err != nil {
+		s.t.Errorf("upgrade: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+	s.subscribes <- string(msg)
+
+	for _, m := range <-s.scripts {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+			return
+		}
+	}
+	// Hold the connection open without sending until the client drops it.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestWebSocketSubscribeMapReconnect(t *testing.T) {
+	stub := &wsStub{t: t, subscribes: make(chan string, 4), scripts: make(chan []string, 4)}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	stub.scripts <- []string{
+		`{"type":"ack"}`,
+		`{"data":[{"s":"AAPL","p":{"last":"189.5"},"v":300,"t":1700000000000},` +
+			`{"s":"IBM","p":{"last":1}},` +
+			`{"s":"MSFT","p":{"last":"oops"}},` +
+			`{"s":"MSFT","p":{"last":410.25},"t":1700000000500}]}`,
+	}
+	stub.scripts <- []string{`{"data":[{"s":"MSFT","p":{"last":411},"t":1700000001000}]}`}
+
+	w, err := NewWebSocket([]string{"AAPL", "MSFT"}, config.WebSocketFeed{
+		URL:       "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Subscribe: `{"op":"subscribe","args":{{json .Symbols}}}`,
+		Fields: config.WebSocketFields{
+			Items:     "data",
+			Symbol:    "s",
+			Price:     "p.last",
+			Volume:    "v",
+			Timestamp: "t",
+		},
+		TimeFormat:       "unix_ms",
+		IdleTimeoutSec:   1,
+		BackoffInitialMs: 10,
+		BackoffMaxMs:     50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ticks := w.Start(ctx)
+
+	const want = `{"op":"subscribe","args":["AAPL","MSFT"]}`
+	if got := recv(t, stub.subscribes); got != want {
+		t.Errorf("subscribe = %s, want %s", got, want)
+	}
+
+	// The bad MSFT price must not cost the good ticks around it.
+	first := recvTick(t, ticks)
+	if first.Symbol != "AAPL" || first.Price != 189.5 || first.Volume != 300 || !first.Time.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("first tick = %+v", first)
+	}
+	if second := recvTick(t, ticks); second.Symbol != "MSFT" || second.Price != 410.25 {
+		t.Errorf("second tick = %+v", second)
+	}
+
+	// The stub now goes quiet; the idle timeout must drop the connection
+	// and the reconnect must subscribe again.
+	if got := recv(t, stub.subscribes); got != want {
+		t.Errorf("resubscribe = %s, want %s", got, want)
+	}
+	if third := recvTick(t, ticks); third.Symbol != "MSFT" || third.Price != 411 {
+		t.Errorf("tick after reconnect = %+v", third)
+	}
+
+	h := w.Health()
+	if h.Ticks != 3 || h.Errors < 2 {
+		t.Errorf("health = %+v, want 3 ticks and errors for the bad price and the idle drop", h)
+	}
+}
+
+func recv(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the stub")
+		return ""
+	}
+}
+
+func recvTick(t *testing.T, ch <-chan Tick) Tick {
+	t.Helper()
+	select {
+	case tick, ok := <-ch:
+		if !ok {
+			t.Fatal("feed closed")
+		}
+		return tick
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a tick")
+		return Tick{}
+	}
+}
@@ -0,0 +1,263 @@
+// Package fix implements the small slice of the FIX tag=value wire format
+// needed by the market data feed: framing, checksums and field access.
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const soh = '\x01'
+
+// TimestampFormat is the FIX UTCTimestamp layout with milliseconds.
+const TimestampFormat = "20060102-15:04:05.000"
+
+// Tags used by the session and market data layers.
+const (
+	TagBeginSeqNo      = 7
+	TagBeginString     = 8
+	TagBodyLength      = 9
+	TagCheckSum        = 10
+	TagEndSeqNo        = 16
+	TagMsgSeqNum       = 34
+	TagMsgType         = 35
+	TagNewSeqNo        = 36
+	TagPossDupFlag     = 43
+	TagRefSeqNum       = 45
+	TagSenderCompID    = 49
+	TagSendingTime     = 52
+	TagSymbol          = 55
+	TagTargetCompID    = 56
+	TagText            = 58
+	TagEncryptMethod   = 98
+	TagHeartBtInt      = 108
+	TagTestReqID       = 112
+	TagGapFillFlag     = 123
+	TagResetSeqNumFlag = 141
+	TagNoRelatedSym    = 146
+	TagMDReqID         = 262
+	TagSubscriptionReq = 263
+	TagMarketDepth     = 264
+	TagMDUpdateType    = 265
+	TagNoMDEntryTypes  = 267
+	TagNoMDEntries     = 268
+	TagMDEntryType     = 269
+	TagMDEntryPx       = 270
+	TagMDEntrySize     = 271
+	TagMDUpdateAction  = 279
+	TagMDEntryPosNo    = 290
+	TagUsername        = 553
+	TagPassword        = 554
+)
+
+// Message types.
+const (
+	MsgHeartbeat       = "0"
+	MsgTestRequest     = "1"
+	MsgResendRequest   = "2"
+	MsgReject          = "3"
+	MsgSequenceReset   = "4"
+	MsgLogout          = "5"
+	MsgLogon           = "A"
+	MsgMDRequest       = "V"
+	MsgMDSnapshot      = "W"
+	MsgMDIncremental   = "X"
+	MsgMDRequestReject = "Y"
+)
+
+type Field struct {
+	Tag   int
+	Value string
+}
+
+// Message is a decoded message without its BeginString, BodyLength and
+// CheckSum fields, which framing handles.
+type Message struct {
+	Fields []Field
+}
+
+func New(msgType string) *Message {
+	return &Message{Fields: []Field{{TagMsgType, msgType}}}
+}
+
+// Add appends a field and returns m for chaining.
+func (m *Message) Add(tag int, value string) *Message {
+	m.Fields = append(m.Fields, Field{tag, value})
+	return m
+}
+
+func (m *Message) AddInt(tag, value int) *Message {
+	return m.Add(tag, strconv.Itoa(value))
+}
+
+// Get returns the first occurrence of tag.
+func (m *Message) Get(tag int) (string, bool) {
+	for _, f := range m.Fields {
+		if f.Tag == tag {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+func (m *Message) Type() string {
+	v, _ := m.Get(TagMsgType)
+	return v
+}
+
+// Int returns tag as an integer, or 0 when absent or malformed.
+func (m *Message) Int(tag int) int {
+	v, _ := m.Get(tag)
+	n, _ := strconv.Atoi(v)
+	return n
+}
+
+// Flag reports whether a boolean tag is set to Y.
+func (m *Message) Flag(tag int) bool {
+	v, _ := m.Get(tag)
+	return v == "Y"
+}
+
+// Time parses a UTCTimestamp tag, with or without milliseconds.
+func (m *Message) Time(tag int) (time.Time, bool) {
+	v, ok := m.Get(tag)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{TimestampFormat, "20060102-15:04:05"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Group splits the repeating group counted by countTag into its entries.
+// Each entry starts at delimTag and runs until the next delimTag or the
+// end of the message.
+func (m *Message) Group(countTag, delimTag int) [][]Field {
+	start := -1
+	for i, f := range m.Fields {
+		if f.Tag == countTag {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	var groups [][]Field
+	for _, f := range m.Fields[start:] {
+		if f.Tag == delimTag {
+			groups = append(groups, nil)
+		}
+		if len(groups) > 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], f)
+		}
+	}
+	return groups
+}
+
+// Encode frames m for the wire, computing BodyLength and CheckSum.
+func Encode(beginString string, m *Message) []byte {
+	var body bytes.Buffer
+	for _, f := range m.Fields {
+		body.WriteString(strconv.Itoa(f.Tag))
+		body.WriteByte('=')
+		body.WriteString(f.Value)
+		body.WriteByte(soh)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "8=%s\x019=%d\x01", beginString, body.Len())
+	out.Write(body.Bytes())
+	fmt.Fprintf(&out, "10=%03d\x01", checksum(out.Bytes()))
+	return out.Bytes()
+}
+
+func checksum(b []byte) int {
+	sum := 0
+	for _, c := range b {
+		sum += int(c)
+	}
+	return sum % 256
+}
+
+var ErrGarbled = errors.New("garbled fix message")
+
+// Reader decodes framed messages from a stream.
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next message. Framing or checksum failures yield
+// ErrGarbled; the stream cannot be resynchronised after one.
+func (r *Reader) Read() (*Message, error) {
+	begin, err := r.r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(begin, []byte("8=")) {
+		return nil, ErrGarbled
+	}
+
+	lenField, err := r.r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(lenField, []byte("9=")) {
+		return nil, ErrGarbled
+	}
+	n, err := strconv.Atoi(string(lenField[2 : len(lenField)-1]))
+	if err != nil || n <= 0 || n > 1<<20 {
+		return nil, ErrGarbled
+	}
+
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r.r, body); err != nil {
+		return nil, err
+	}
+	if body[n-1] != soh {
+		return nil, ErrGarbled
+	}
+	trailer := make([]byte, 7)
+	if _, err := io.ReadFull(r.r, trailer); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(trailer, []byte("10=")) || trailer[6] != soh {
+		return nil, ErrGarbled
+	}
+
+	want, err := strconv.Atoi(string(trailer[3:6]))
+	if err != nil {
+		return nil, ErrGarbled
+	}
+	sum := checksum(begin) + checksum(lenField) + checksum(body)
+	if sum%256 != want {
+		return nil, ErrGarbled
+	}
+
+	m := &Message{}
+	for _, raw := range bytes.Split(body[:len(body)-1], []byte{soh}) {
+		eq := bytes.IndexByte(raw, '=')
+		if eq <= 0 {
+			return nil, ErrGarbled
+		}
+		tag, err := strconv.Atoi(string(raw[:eq]))
+		if err != nil {
+			return nil, ErrGarbled
+		}
+		m.Fields = append(m.Fields, Field{tag, string(raw[eq+1:])})
+	}
+	return m, nil
+}
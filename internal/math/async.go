@@ -0,0 +1,81 @@
+package math
+
+import (
+	"math"
+	"time"
+)
+
+// HayashiYoshida estimates the integrated covariance of two
+// asynchronously observed log-price series. Every pair of price-change
+// intervals that overlap in time contributes the product of its log
+// returns, so no series has to be resampled onto the other's clock.
+// Times must be ascending. With x and y the same series the result is
+// the realized variance.
+func HayashiYoshida(x []float64, tx []time.Time, y []float64, ty []time.Time) float64 {
+	if len(x) < 2 || len(y) < 2 || len(x) != len(tx) || len(y) != len(ty) {
+		return 0
+	}
+
+	sum := 0.0
+	i, j := 1, 1
+	for i < len(x) && j < len(y) {
+		// (tx[i-1], tx[i]] and (ty[j-1], ty[j]] overlap.
+		if tx[i-1].Before(ty[j]) && ty[j-1].Before(tx[i]) {
+			sum += math.Log(x[i]/x[i-1]) * math.Log(y[j]/y[j-1])
+		}
+
+		// Move past whichever interval ends first.
+		switch {
+		case tx[i].Before(ty[j]):
+			i++
+		case ty[j].Before(tx[i]):
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return sum
+}
+
+// CommonSpan trims timestamped series to the interval every one of them
+// covers, so estimates built from different series describe the same
+// stretch of time. A series that has no point exactly at the start keeps
+// its last one before it, so the interval straddling the start, often
+// the only one a slow series has there, is not lost. It returns false
+// when the series do not overlap.
+func CommonSpan(values [][]float64, times [][]time.Time) ([][]float64, [][]time.Time, bool) {
+	var start, end time.Time
+	for k, ts := range times {
+		if len(ts) == 0 {
+			return nil, nil, false
+		}
+		if k == 0 || ts[0].After(start) {
+			start = ts[0]
+		}
+		if k == 0 || ts[len(ts)-1].Before(end) {
+			end = ts[len(ts)-1]
+		}
+	}
+	if !start.Before(end) {
+		return nil, nil, false
+	}
+
+	outV := make([][]float64, len(values))
+	outT := make([][]time.Time, len(times))
+	for k, ts := range times {
+		lo, hi := 0, len(ts)
+		for lo < hi && ts[lo].Before(start) {
+			lo++
+		}
+		if lo > 0 && (lo == hi || ts[lo].After(start)) {
+			lo--
+		}
+		for hi > lo && ts[hi-1].After(end) {
+			hi--
+		}
+		outV[k] = values[k][lo:hi]
+		outT[k] = ts[lo:hi]
+	}
+	return outV, outT, true
+}
@@ -0,0 +1,87 @@
+package math
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// naiveHY sums the return products of every pair of overlapping
+// intervals, O(n·m), straight from the definition.
+func naiveHY(x []float64, tx []time.Time, y []float64, ty []time.Time) float64 {
+	sum := 0.0
+	for i := 1; i < len(x); i++ {
+		for j := 1; j < len(y); j++ {
+			if tx[i-1].Before(ty[j]) && ty[j-1].Before(tx[i]) {
+				sum += math.Log(x[i]/x[i-1]) * math.Log(y[j]/y[j-1])
+			}
+		}
+	}
+	return sum
+}
+
+// ticks draws a random walk observed at random, ascending whole seconds,
+// so two series often share timestamps.
+func ticks(rng *rand.Rand, n int) ([]float64, []time.Time) {
+	prices := make([]float64, n)
+	times := make([]time.Time, n)
+	p, at := 100.0, time.Unix(1700000000, 0)
+	for k := range prices {
+		p *= math.Exp(0.01 * rng.NormFloat64())
+		at = at.Add(time.Duration(1+rng.Intn(4)) * time.Second)
+		prices[k], times[k] = p, at
+	}
+	return prices, times
+}
+
+func TestHayashiYoshidaMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(23))
+	for trial := 0; trial < 200; trial++ {
+		x, tx := ticks(rng, 2+rng.Intn(30))
+		y, ty := ticks(rng, 2+rng.Intn(30))
+		got, want := HayashiYoshida(x, tx, y, ty), naiveHY(x, tx, y, ty)
+		if math.Abs(got-want) > 1e-15 {
+			t.Fatalf("trial %d: HayashiYoshida = %v, naive = %v", trial, got, want)
+		}
+		if got := HayashiYoshida(y, ty, x, tx); math.Abs(got-want) > 1e-15 {
+			t.Fatalf("trial %d: swapped HayashiYoshida = %v, naive = %v", trial, got, want)
+		}
+	}
+}
+
+func TestHayashiYoshidaRealizedVariance(t *testing.T) {
+	x, tx := ticks(rand.New(rand.NewSource(29)), 50)
+	rv := 0.0
+	for k := 1; k < len(x); k++ {
+		r := math.Log(x[k] / x[k-1])
+		rv += r * r
+	}
+	if got := HayashiYoshida(x, tx, x, tx); math.Abs(got-rv) > 1e-15 {
+		t.Errorf("HayashiYoshida of a series with itself = %v, realized variance %v", got, rv)
+	}
+}
+
+func TestCommonSpan(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	sec := func(s ...int) []time.Time {
+		out := make([]time.Time, len(s))
+		for k, v := range s {
+			out[k] = t0.Add(time.Duration(v) * time.Second)
+		}
+		return out
+	}
+	values := [][]float64{{1, 2, 3, 4, 5}, {6, 7, 8}}
+	times := [][]time.Time{sec(0, 2, 4, 6, 8), sec(3, 5, 7)}
+
+	v, ts, ok := CommonSpan(values, times)
+	// The first series keeps its point at 2 s, before the span starts at
+	// 3 s, so its interval to 4 s still pairs with the second series.
+	if !ok || len(v[0]) != 3 || v[0][0] != 2 || v[0][2] != 4 || len(v[1]) != 3 || !ts[0][0].Equal(t0.Add(2*time.Second)) {
+		t.Errorf("CommonSpan = %v, %v, %v", v, ts, ok)
+	}
+
+	if _, _, ok := CommonSpan(values, [][]time.Time{sec(0, 1, 2, 3, 4), sec(5, 6, 7)}); ok {
+		t.Error("CommonSpan of disjoint series reported an overlap")
+	}
+}
@@ -0,0 +1,55 @@
+package math
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// EigenSym decomposes a symmetric matrix. Eigenvalues come back in
+// descending order and vecs[k] is the unit eigenvector for vals[k], its
+// sign chosen so the components sum to a non-negative number; for a
+// correlation matrix that makes the market mode point the way the market
+// moves. ok is false if the factorization fails.
+func EigenSym(a [][]float64) (vals []float64, vecs [][]float64, ok bool) {
+	n := len(a)
+	sym := mat.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			sym.SetSym(i, j, a[i][j])
+		}
+	}
+
+	var eig mat.EigenSym
+	if !eig.Factorize(sym, true) {
+		return nil, nil, false
+	}
+	raw := eig.Values(nil)
+	var cols mat.Dense
+	eig.VectorsTo(&cols)
+
+	order := make([]int, n)
+	for k := range order {
+		order[k] = k
+	}
+	sort.Slice(order, func(x, y int) bool { return raw[order[x]] > raw[order[y]] })
+
+	vals = make([]float64, n)
+	vecs = make([][]float64, n)
+	for k, src := range order {
+		vals[k] = raw[src]
+		vec := make([]float64, n)
+		sum := 0.0
+		for i := range vec {
+			vec[i] = cols.At(i, src)
+			sum += vec[i]
+		}
+		if sum < 0 {
+			for i := range vec {
+				vec[i] = -vec[i]
+			}
+		}
+		vecs[k] = vec
+	}
+	return vals, vecs, true
+}
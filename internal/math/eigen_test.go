@@ -0,0 +1,75 @@
+package math
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"matrixpulse/internal/math/mathtest"
+)
+
+func TestEigenSymDecomposes(t *testing.T) {
+	rng := rand.New(rand.NewSource(37))
+	for trial := 0; trial < 50; trial++ {
+		n := 1 + rng.Intn(10)
+		a := square(n)
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				a[i][j] = rng.NormFloat64()
+				a[j][i] = a[i][j]
+			}
+		}
+
+		vals, vecs, ok := EigenSym(a)
+		if !ok || len(vals) != n || len(vecs) != n {
+			t.Fatalf("trial %d: EigenSym returned %d values, %d vectors, ok %v", trial, len(vals), len(vecs), ok)
+		}
+		for k := range vals {
+			if k > 0 && vals[k] > vals[k-1] {
+				t.Fatalf("trial %d: eigenvalues not descending: %v", trial, vals)
+			}
+			// A·v = λ·v, with v of unit length and summing to >= 0.
+			sum, norm := 0.0, 0.0
+			for i := 0; i < n; i++ {
+				av := 0.0
+				for j := 0; j < n; j++ {
+					av += a[i][j] * vecs[k][j]
+				}
+				if math.Abs(av-vals[k]*vecs[k][i]) > 1e-9 {
+					t.Fatalf("trial %d: A·v%d ≠ λ·v at %d: %v vs %v", trial, k, i, av, vals[k]*vecs[k][i])
+				}
+				sum += vecs[k][i]
+				norm += vecs[k][i] * vecs[k][i]
+			}
+			if math.Abs(norm-1) > 1e-9 || sum < -1e-12 {
+				t.Fatalf("trial %d: v%d has norm² %v and sum %v", trial, k, norm, sum)
+			}
+		}
+	}
+}
+
+func TestEigenSymKnownSpectrum(t *testing.T) {
+	vals, vecs, ok := EigenSym(mathtest.WithSpectrum([]float64{2.5, 0.8, 0.4, 0.3}))
+	if !ok {
+		t.Fatal("EigenSym failed")
+	}
+	for k, want := range []float64{2.5, 0.8, 0.4, 0.3} {
+		if math.Abs(vals[k]-want) > 1e-12 {
+			t.Fatalf("eigenvalues = %v", vals)
+		}
+		dot := 0.0
+		for i := range vecs[k] {
+			dot += vecs[k][i] * mathtest.Hadamard4[k][i]
+		}
+		if math.Abs(math.Abs(dot)-1) > 1e-12 {
+			t.Errorf("eigenvector %d = %v, want ±%v", k, vecs[k], mathtest.Hadamard4[k])
+		}
+	}
+	// The market mode points the way the market moves.
+	for _, v := range vecs[0] {
+		if math.Abs(v-0.5) > 1e-12 {
+			t.Errorf("first eigenvector = %v, want all +1/2", vecs[0])
+			break
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Package mathtest provides matrices with known spectra for tests.
+package mathtest
+
+// Hadamard4 is an orthonormal basis whose vectors all have entries of
+// ±1/2, so Σ λ_k·u_k·u_kᵀ has a unit diagonal whenever the λ_k sum to 4:
+// a correlation matrix with exactly the spectrum λ.
+var Hadamard4 = [][]float64{
+	{0.5, 0.5, 0.5, 0.5},
+	{0.5, -0.5, 0.5, -0.5},
+	{0.5, 0.5, -0.5, -0.5},
+	{0.5, -0.5, -0.5, 0.5},
+}
+
+// WithSpectrum returns the 4×4 matrix with eigenvalues vals and the rows
+// of Hadamard4 as eigenvectors. It is a correlation matrix when vals sum
+// to 4.
+func WithSpectrum(vals []float64) [][]float64 {
+	out := make([][]float64, len(Hadamard4))
+	for i := range out {
+		out[i] = make([]float64, len(Hadamard4))
+	}
+	for k, v := range vals {
+		for i := range out {
+			for j := range out {
+				out[i][j] += v * Hadamard4[k][i] * Hadamard4[k][j]
+			}
+		}
+	}
+	return out
+}
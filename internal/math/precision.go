@@ -0,0 +1,216 @@
+package math
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Inverse inverts a symmetric positive definite matrix through its
+// Cholesky factorization. ok is false when the matrix is singular or not
+// positive definite, as a sample covariance with more symbols than
+// observations is.
+func Inverse(a [][]float64) (inv [][]float64, ok bool) {
+	n := len(a)
+	sym := mat.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			sym.SetSym(i, j, a[i][j])
+		}
+	}
+
+	var chol mat.Cholesky
+	if !chol.Factorize(sym) {
+		return nil, false
+	}
+	var dst mat.SymDense
+	if err := chol.InverseTo(&dst); err != nil {
+		return nil, false
+	}
+
+	inv = square(n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			inv[i][j] = dst.At(i, j)
+		}
+	}
+	return inv, true
+}
+
+// PartialCorrelation turns a precision matrix Θ into partial
+// correlations, -Θij / √(Θii Θjj): the correlation of i and j once every
+// other variable is held fixed.
+func PartialCorrelation(prec [][]float64) [][]float64 {
+	n := len(prec)
+	out := square(n)
+	for i := 0; i < n; i++ {
+		out[i][i] = 1
+		for j := i + 1; j < n; j++ {
+			if d := prec[i][i] * prec[j][j]; d > 0 {
+				r := -prec[i][j] / math.Sqrt(d)
+				out[i][j] = r
+				out[j][i] = r
+			}
+		}
+	}
+	return out
+}
+
+// Glasso estimates a sparse precision matrix from a covariance by
+// maximizing the L1-penalized Gaussian likelihood (Friedman, Hastie and
+// Tibshirani 2008). Larger Lambda sets more entries to exactly zero, so
+// only pairs with a direct link survive. A sweep stops the fit once the
+// average change in the covariance estimate falls below Tol times the
+// average off-diagonal magnitude of the input.
+//
+// A Glasso keeps its last solution and starts the next Fit from it, so
+// refitting a slowly changing matrix takes a sweep or two.
+type Glasso struct {
+	Lambda  float64
+	MaxIter int
+	Tol     float64
+
+	w    [][]float64
+	beta [][]float64 // beta[j] holds the lasso coefficients of column j
+}
+
+// Fit returns the precision estimate for s and whether it converged
+// within MaxIter sweeps.
+func (g *Glasso) Fit(s [][]float64) (prec [][]float64, converged bool) {
+	n := len(s)
+	if len(g.w) != n {
+		g.w = square(n)
+		g.beta = square(n)
+		for i := range s {
+			copy(g.w[i], s[i])
+		}
+	}
+	w, beta := g.w, g.beta
+	for i := range s {
+		w[i][i] = s[i][i] + g.Lambda
+	}
+
+	scale := 0.0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i != j {
+				scale += math.Abs(s[i][j])
+			}
+		}
+	}
+	if n > 1 {
+		scale /= float64(n * (n - 1))
+	}
+	if scale == 0 {
+		scale = 1
+	}
+
+	for iter := 0; iter < g.MaxIter && !converged; iter++ {
+		change := 0.0
+		for j := 0; j < n; j++ {
+			b := beta[j]
+			lassoColumn(w, s, j, g.Lambda, b)
+
+			// w12 = W11·β
+			for i := 0; i < n; i++ {
+				if i == j {
+					continue
+				}
+				v := 0.0
+				for k, bk := range b {
+					if k != j && bk != 0 {
+						v += w[i][k] * bk
+					}
+				}
+				change += math.Abs(v - w[i][j])
+				w[i][j] = v
+				w[j][i] = v
+			}
+		}
+		if n > 1 {
+			change /= float64(n * (n - 1))
+		}
+		converged = change < g.Tol*scale
+	}
+
+	prec = square(n)
+	for j := 0; j < n; j++ {
+		b := beta[j]
+		dot := 0.0
+		for k, bk := range b {
+			if k != j {
+				dot += w[j][k] * bk
+			}
+		}
+		theta := 1 / (w[j][j] - dot)
+		prec[j][j] = theta
+		for k, bk := range b {
+			if k != j {
+				prec[k][j] = -bk * theta
+			}
+		}
+	}
+	// Symmetrize; the two halves agree only up to the tolerance.
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			v := (prec[i][j] + prec[j][i]) / 2
+			prec[i][j] = v
+			prec[j][i] = v
+		}
+	}
+	return prec, converged
+}
+
+// lassoColumn solves min ½βᵀW11β − s12ᵀβ + λ‖β‖₁ for column j by
+// coordinate descent, starting from and updating b in place. It keeps
+// the gradient s12 − W11β current as coefficients move, so coordinates
+// that stay at zero cost nothing beyond their soft-threshold test.
+func lassoColumn(w, s [][]float64, j int, lambda float64, b []float64) {
+	n := len(w)
+	grad := make([]float64, n)
+	for k := 0; k < n; k++ {
+		if k == j {
+			continue
+		}
+		g := s[k][j]
+		for l, bl := range b {
+			if bl != 0 && l != j {
+				g -= w[k][l] * bl
+			}
+		}
+		grad[k] = g
+	}
+
+	for sweep := 0; sweep < 100; sweep++ {
+		delta := 0.0
+		for k := 0; k < n; k++ {
+			if k == j {
+				continue
+			}
+			v := softThreshold(grad[k]+w[k][k]*b[k], lambda) / w[k][k]
+			d := v - b[k]
+			if d == 0 {
+				continue
+			}
+			b[k] = v
+			delta = math.Max(delta, math.Abs(d))
+			for i := 0; i < n; i++ {
+				grad[i] -= w[i][k] * d
+			}
+		}
+		if delta < 1e-6 {
+			return
+		}
+	}
+}
+
+func softThreshold(x, t float64) float64 {
+	switch {
+	case x > t:
+		return x - t
+	case x < -t:
+		return x + t
+	default:
+		return 0
+	}
+}
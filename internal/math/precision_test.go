@@ -0,0 +1,132 @@
+package math
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// sampleCov draws n observations from the factor model in factorSeries
+// and returns their covariance.
+func sampleCov(rng *rand.Rand, p, n, blocks int, rho float64) [][]float64 {
+	_, s := centered(factorSeries(rng, p, n, blocks, rho))
+	return s
+}
+
+func TestInverse(t *testing.T) {
+	s := sampleCov(rand.New(rand.NewSource(21)), 8, 200, 2, 0.5)
+	inv, ok := Inverse(s)
+	if !ok {
+		t.Fatal("Inverse failed on a positive definite matrix")
+	}
+	for i := range s {
+		for j := range s {
+			v := 0.0
+			for k := range s {
+				v += s[i][k] * inv[k][j]
+			}
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if math.Abs(v-want) > 1e-9 {
+				t.Fatalf("(S·S⁻¹)[%d][%d] = %v", i, j, v)
+			}
+		}
+	}
+
+	if _, ok := Inverse([][]float64{{1, 1}, {1, 1}}); ok {
+		t.Error("Inverse succeeded on a singular matrix")
+	}
+}
+
+func TestPartialCorrelation(t *testing.T) {
+	prec := [][]float64{{4, -1}, {-1, 1}}
+	if r := PartialCorrelation(prec)[0][1]; math.Abs(r-0.5) > 1e-12 {
+		t.Errorf("partial correlation = %v, want 0.5", r)
+	}
+}
+
+// TestGlassoSmallLambda checks that with almost no penalty the lasso
+// recovers the plain inverse.
+func TestGlassoSmallLambda(t *testing.T) {
+	s := sampleCov(rand.New(rand.NewSource(22)), 8, 200, 2, 0.5)
+	want, _ := Inverse(s)
+
+	g := &Glasso{Lambda: 1e-9, MaxIter: 500, Tol: 1e-10}
+	got, converged := g.Fit(s)
+	if !converged {
+		t.Fatal("glasso did not converge")
+	}
+	for i := range s {
+		for j := range s {
+			if d := math.Abs(got[i][j] - want[i][j]); d > 1e-4*(1+math.Abs(want[i][j])) {
+				t.Errorf("prec[%d][%d] = %v, inverse %v", i, j, got[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+// TestGlassoOptimality checks the KKT conditions of the penalized
+// likelihood: with W = Θ⁻¹, |Wij − Sij| ≤ λ off the diagonal, with
+// equality and sign opposite to Θij wherever Θij is non-zero. When every
+// cross-block |Sij| is at most λ the solution must be block diagonal
+// (Mazumder and Hastie 2012), so the two independent blocks must stay
+// unlinked. A warm refit must agree with the cold one.
+func TestGlassoOptimality(t *testing.T) {
+	s := sampleCov(rand.New(rand.NewSource(23)), 10, 1000, 2, 0.6)
+	const lambda = 0.1
+	for i := range s {
+		for j := range s {
+			if i%2 != j%2 && math.Abs(s[i][j]) > lambda {
+				t.Fatalf("sample too noisy for the block check: |S[%d][%d]| = %v", i, j, s[i][j])
+			}
+		}
+	}
+
+	g := &Glasso{Lambda: lambda, MaxIter: 500, Tol: 1e-9}
+	prec, converged := g.Fit(s)
+	if !converged {
+		t.Fatal("glasso did not converge")
+	}
+	w, ok := Inverse(prec)
+	if !ok {
+		t.Fatal("glasso estimate is not positive definite")
+	}
+
+	const slack = 1e-4
+	zeros := 0
+	for i := range s {
+		for j := range s {
+			if i == j {
+				continue
+			}
+			d := w[i][j] - s[i][j]
+			if prec[i][j] == 0 {
+				zeros++
+				if math.Abs(d) > lambda+slack {
+					t.Errorf("zero entry (%d,%d): |W-S| = %v > λ", i, j, math.Abs(d))
+				}
+				continue
+			}
+			if math.Abs(math.Abs(d)-lambda) > slack || d*prec[i][j] < 0 {
+				t.Errorf("entry (%d,%d): W-S = %v with Θ = %v, want ±λ of the same sign", i, j, d, prec[i][j])
+			}
+			if i%2 != j%2 {
+				t.Errorf("entry (%d,%d) links two independent blocks: Θ = %v", i, j, prec[i][j])
+			}
+		}
+	}
+	if zeros == 0 {
+		t.Error("no entries shrunk to zero")
+	}
+
+	warm, _ := g.Fit(s)
+	for i := range s {
+		for j := range s {
+			if math.Abs(warm[i][j]-prec[i][j]) > 1e-6 {
+				t.Fatalf("warm refit differs at (%d,%d): %v vs %v", i, j, warm[i][j], prec[i][j])
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package math
+
+import (
+	"math"
+	"sort"
+)
+
+// Ranks returns the 1-based rank of each value, giving tied values the
+// average of the ranks they span.
+func Ranks(data []float64) []float64 {
+	n := len(data)
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool { return data[idx[a]] < data[idx[b]] })
+
+	ranks := make([]float64, n)
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && data[idx[j]] == data[idx[i]] {
+			j++
+		}
+		r := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			ranks[idx[k]] = r
+		}
+		i = j
+	}
+	return ranks
+}
+
+// Pearson is the linear correlation of x and y, or 0 when either is
+// constant or the lengths differ.
+func Pearson(x, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return 0
+	}
+	mx, my := Mean(x), Mean(y)
+	sx, sy := StdDev(x, mx), StdDev(y, my)
+	if sx == 0 || sy == 0 {
+		return 0
+	}
+	return Covariance(x, y, mx, my) / (sx * sy)
+}
+
+// Spearman is the Pearson correlation of the ranks of x and y.
+func Spearman(x, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return 0
+	}
+	return Pearson(Ranks(x), Ranks(y))
+}
+
+// Kendall is Kendall's tau-b, which corrects for ties in either series.
+// It uses Knight's algorithm: sort the pairs by x, then count the swaps a
+// merge sort needs to order them by y, giving O(n log n) instead of
+// comparing every pair.
+func Kendall(x, y []float64) float64 {
+	n := len(x)
+	if n != len(y) || n < 2 {
+		return 0
+	}
+
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		if x[idx[a]] != x[idx[b]] {
+			return x[idx[a]] < x[idx[b]]
+		}
+		return y[idx[a]] < y[idx[b]]
+	})
+
+	// Pairs tied in x, and of those the ones tied in y as well.
+	var tiedX, tiedXY int64
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && x[idx[j]] == x[idx[i]] {
+			j++
+		}
+		tiedX += int64(j-i) * int64(j-i-1) / 2
+		for k := i; k < j; {
+			l := k + 1
+			for l < j && y[idx[l]] == y[idx[k]] {
+				l++
+			}
+			tiedXY += int64(l-k) * int64(l-k-1) / 2
+			k = l
+		}
+		i = j
+	}
+
+	ys := make([]float64, n)
+	for i, k := range idx {
+		ys[i] = y[k]
+	}
+	swaps := mergeCount(ys, make([]float64, n))
+
+	var tiedY int64
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && ys[j] == ys[i] {
+			j++
+		}
+		tiedY += int64(j-i) * int64(j-i-1) / 2
+		i = j
+	}
+
+	pairs := int64(n) * int64(n-1) / 2
+	// Concordant minus discordant, counting each kind of tie as neither.
+	diff := pairs - tiedX - tiedY + tiedXY - 2*swaps
+	den := math.Sqrt(float64(pairs-tiedX) * float64(pairs-tiedY))
+	if den == 0 {
+		return 0
+	}
+	return float64(diff) / den
+}
+
+// mergeCount sorts a in place and returns the number of inversions.
+func mergeCount(a, buf []float64) int64 {
+	n := len(a)
+	if n < 2 {
+		return 0
+	}
+	mid := n / 2
+	swaps := mergeCount(a[:mid], buf[:mid]) + mergeCount(a[mid:], buf[mid:])
+
+	i, j, k := 0, mid, 0
+	for i < mid && j < n {
+		if a[j] < a[i] {
+			buf[k] = a[j]
+			swaps += int64(mid - i)
+			j++
+		} else {
+			buf[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(buf[k:], a[i:mid])
+	copy(buf[k:], a[j:])
+	copy(a, buf[:n])
+	return swaps
+}
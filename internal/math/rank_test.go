@@ -0,0 +1,108 @@
+package math
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRanksTies(t *testing.T) {
+	got := Ranks([]float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3, 5})
+	want := []float64{4.5, 1.5, 6, 1.5, 8, 11, 3, 10, 8, 4.5, 8}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Ranks = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSpearmanMonotone(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5, 6}
+	y := []float64{1, 8, 27, 64, 125, 216}
+	if r := Spearman(x, y); math.Abs(r-1) > 1e-12 {
+		t.Errorf("Spearman of a monotone map = %v, want 1", r)
+	}
+}
+
+// naiveKendall is tau-b straight from the definition, O(n²).
+func naiveKendall(x, y []float64) float64 {
+	var conc, disc, tx, ty float64
+	for i := 0; i < len(x); i++ {
+		for j := i + 1; j < len(x); j++ {
+			dx, dy := x[i]-x[j], y[i]-y[j]
+			switch {
+			case dx == 0 && dy == 0:
+			case dx == 0:
+				tx++
+			case dy == 0:
+				ty++
+			case dx*dy > 0:
+				conc++
+			default:
+				disc++
+			}
+		}
+	}
+	den := math.Sqrt((conc + disc + tx) * (conc + disc + ty))
+	if den == 0 {
+		return 0
+	}
+	return (conc - disc) / den
+}
+
+func TestKendallMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for trial := 0; trial < 200; trial++ {
+		n := 2 + rng.Intn(60)
+		levels := 1 + rng.Intn(6) // few levels, so ties are common
+		x := make([]float64, n)
+		y := make([]float64, n)
+		for i := range x {
+			x[i] = float64(rng.Intn(levels))
+			y[i] = float64(rng.Intn(levels)) + 0.5*x[i]
+			if trial%3 == 0 {
+				y[i] = math.Round(y[i])
+			}
+		}
+
+		got, want := Kendall(x, y), naiveKendall(x, y)
+		if math.Abs(got-want) > 1e-12 {
+			t.Fatalf("trial %d: Kendall = %v, naive = %v\nx = %v\ny = %v", trial, got, want, x, y)
+		}
+	}
+}
+
+func TestNearestPSD(t *testing.T) {
+	// A and B move together, as do A and C, yet B and C move against
+	// each other: no set of series has these correlations.
+	bad := [][]float64{
+		{1, 0.9, 0.9},
+		{0.9, 1, -0.9},
+		{0.9, -0.9, 1},
+	}
+	got := NearestPSD(bad)
+	vals, _, ok := EigenSym(got)
+	if !ok || vals[len(vals)-1] < -1e-12 {
+		t.Fatalf("eigenvalues after projection = %v", vals)
+	}
+	for i := range got {
+		if math.Abs(got[i][i]-1) > 1e-12 {
+			t.Errorf("diagonal %d = %v, want 1", i, got[i][i])
+		}
+		for j := range got {
+			if got[i][j] != got[j][i] {
+				t.Errorf("[%d][%d] = %v, [%d][%d] = %v", i, j, got[i][j], j, i, got[j][i])
+			}
+		}
+	}
+	// The signs survive; only the impossible combination is softened.
+	if got[0][1] <= 0 || got[0][2] <= 0 || got[1][2] >= 0 || got[1][2] <= -0.9 {
+		t.Errorf("projection = %v", got)
+	}
+
+	ok3 := [][]float64{{1, 0.5, 0.2}, {0.5, 1, 0.3}, {0.2, 0.3, 1}}
+	if got := NearestPSD(ok3); !reflect.DeepEqual(got, ok3) {
+		t.Errorf("PSD input changed to %v", got)
+	}
+}
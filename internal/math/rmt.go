@@ -0,0 +1,88 @@
+package math
+
+import "math"
+
+// MarchenkoPastur returns the edges of the eigenvalue band of a
+// correlation matrix built from n independent series of t observations.
+// Eigenvalues inside the band are indistinguishable from noise; those
+// above the upper edge carry genuine common structure.
+func MarchenkoPastur(n, t int) (lower, upper float64) {
+	if n < 1 || t < 1 {
+		return 0, 0
+	}
+	q := math.Sqrt(float64(n) / float64(t))
+	return (1 - q) * (1 - q), (1 + q) * (1 + q)
+}
+
+// ClipEigen cleans a correlation matrix given its eigen decomposition
+// (as returned by EigenSym) by eigenvalue clipping: every eigenvalue at
+// or below bound is replaced by their average, which keeps the trace,
+// and the matrix is rebuilt and rescaled to a unit diagonal.
+func ClipEigen(vals []float64, vecs [][]float64, bound float64) [][]float64 {
+	clipped := append([]float64(nil), vals...)
+
+	noise, count := 0.0, 0
+	for _, v := range clipped {
+		if v <= bound {
+			noise += v
+			count++
+		}
+	}
+	if count > 0 {
+		noise /= float64(count)
+		for k, v := range clipped {
+			if v <= bound {
+				clipped[k] = noise
+			}
+		}
+	}
+	return rebuild(clipped, vecs)
+}
+
+// NearestPSD projects a symmetric matrix with a unit diagonal, such as a
+// pairwise Kendall matrix, onto the positive semi-definite matrices by
+// zeroing its negative eigenvalues, then restores the unit diagonal.
+// A matrix that is already PSD comes back unchanged.
+func NearestPSD(cor [][]float64) [][]float64 {
+	vals, vecs, ok := EigenSym(cor)
+	if !ok || len(vals) == 0 || vals[len(vals)-1] >= 0 {
+		return cor
+	}
+	clipped := append([]float64(nil), vals...)
+	for k, v := range clipped {
+		if v < 0 {
+			clipped[k] = 0
+		}
+	}
+	return rebuild(clipped, vecs)
+}
+
+// rebuild forms Σ vals[k]·vecs[k]·vecs[k]ᵀ and rescales it to a unit
+// diagonal.
+func rebuild(vals []float64, vecs [][]float64) [][]float64 {
+	n := len(vals)
+	out := square(n)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			c := 0.0
+			for k, v := range vals {
+				c += v * vecs[k][i] * vecs[k][j]
+			}
+			out[i][j] = c
+			out[j][i] = c
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if d := math.Sqrt(out[i][i] * out[j][j]); d > 0 {
+				out[i][j] /= d
+				out[j][i] = out[i][j]
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		out[i][i] = 1
+	}
+	return out
+}
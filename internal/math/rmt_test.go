@@ -0,0 +1,61 @@
+package math
+
+import (
+	"math"
+	"testing"
+
+	"matrixpulse/internal/math/mathtest"
+)
+
+func TestMarchenkoPastur(t *testing.T) {
+	for _, tc := range []struct {
+		n, t         int
+		lower, upper float64
+	}{
+		{50, 200, 0.25, 2.25}, // √(N/T) = 1/2
+		{100, 100, 0, 4},
+		{1, 10000, 0.99 * 0.99, 1.01 * 1.01},
+		{0, 100, 0, 0},
+	} {
+		lower, upper := MarchenkoPastur(tc.n, tc.t)
+		if math.Abs(lower-tc.lower) > 1e-12 || math.Abs(upper-tc.upper) > 1e-12 {
+			t.Errorf("MarchenkoPastur(%d, %d) = %v, %v, want %v, %v", tc.n, tc.t, lower, upper, tc.lower, tc.upper)
+		}
+	}
+}
+
+func TestClipEigen(t *testing.T) {
+	vals := []float64{2.5, 0.8, 0.4, 0.3}
+	cor := mathtest.WithSpectrum(vals)
+	got, vecs, ok := EigenSym(cor)
+	if !ok {
+		t.Fatal("EigenSym failed")
+	}
+	for k := range vals {
+		if math.Abs(got[k]-vals[k]) > 1e-12 {
+			t.Fatalf("test matrix spectrum = %v, want %v", got, vals)
+		}
+	}
+
+	// Everything at or below 1 is noise: 0.8, 0.4 and 0.3 become their
+	// average 0.5, which keeps the trace and so the unit diagonal.
+	cleaned := ClipEigen(got, vecs, 1)
+	want := mathtest.WithSpectrum([]float64{2.5, 0.5, 0.5, 0.5})
+	for i := range want {
+		for j := range want {
+			if math.Abs(cleaned[i][j]-want[i][j]) > 1e-12 {
+				t.Fatalf("ClipEigen = %v, want %v", cleaned, want)
+			}
+		}
+	}
+
+	// A bound below every eigenvalue leaves the matrix as it was.
+	same := ClipEigen(got, vecs, 0.1)
+	for i := range cor {
+		for j := range cor {
+			if math.Abs(same[i][j]-cor[i][j]) > 1e-12 {
+				t.Fatalf("ClipEigen with nothing to clip = %v, want %v", same, cor)
+			}
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package math
+
+import "math"
+
+// Shrinkage estimators pull a sample covariance towards a structured
+// target, Σ = δF + (1-δ)S, trading a little bias for far less noise when
+// there are few observations per variable. Each function takes the return
+// series (one per variable, equal lengths) and returns the intensity δ in
+// [0, 1]; ShrinkTo applies it.
+
+// LedoitWolf is the Ledoit–Wolf (2004) intensity for the scaled identity
+// target returned by IdentityTarget.
+func LedoitWolf(series [][]float64) float64 {
+	x, s := centered(series)
+	p, n := len(x), len(x[0])
+	mu := trace(s) / float64(p)
+
+	// δ: distance of S from the target; β: how noisy S itself is.
+	delta := 0.0
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			d := s[i][j]
+			if i == j {
+				d -= mu
+			}
+			delta += d * d
+		}
+	}
+
+	// Σ_t ||x_t x_tᵀ - S||² = Σ_t |x_t|⁴ - n·||S||².
+	fourth := 0.0
+	for t := 0; t < n; t++ {
+		sq := 0.0
+		for i := 0; i < p; i++ {
+			sq += x[i][t] * x[i][t]
+		}
+		fourth += sq * sq
+	}
+	beta := (fourth - float64(n)*frobenius2(s)) / float64(n*n)
+	beta = math.Min(beta, delta)
+
+	if delta == 0 {
+		return 1
+	}
+	return clamp01(beta / delta)
+}
+
+// OAS is the Oracle Approximating Shrinkage intensity (Chen et al. 2010)
+// for the scaled identity target. It assumes roughly Gaussian returns and
+// shrinks harder than Ledoit–Wolf on very short samples.
+func OAS(series [][]float64) float64 {
+	_, s := centered(series)
+	p, n := float64(len(s)), float64(len(series[0]))
+	mu := trace(s) / p
+	alpha := frobenius2(s) / (p * p)
+
+	num := alpha + mu*mu
+	den := (n + 1) * (alpha - mu*mu/p)
+	if den <= 0 {
+		return 1
+	}
+	return clamp01(num / den)
+}
+
+// ConstantCorrelation is the Ledoit–Wolf (2003) intensity for the target
+// returned by ConstantCorrelationTarget, which keeps every variance and
+// sets each correlation to the average one.
+func ConstantCorrelation(series [][]float64) float64 {
+	x, s := centered(series)
+	p, n := len(x), len(x[0])
+	target, rbar := constantCorrelation(s)
+
+	// π: sum of asymptotic variances of the entries of S.
+	// ρ: sum of asymptotic covariances between S and the target.
+	// γ: misspecification of the target.
+	pi, rho, gamma := 0.0, 0.0, 0.0
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			piIJ, thetaII, thetaJJ := 0.0, 0.0, 0.0
+			for t := 0; t < n; t++ {
+				c := x[i][t]*x[j][t] - s[i][j]
+				piIJ += c * c
+				thetaII += (x[i][t]*x[i][t] - s[i][i]) * c
+				thetaJJ += (x[j][t]*x[j][t] - s[j][j]) * c
+			}
+			piIJ /= float64(n)
+			pi += piIJ
+
+			if i == j {
+				rho += piIJ
+			} else if s[i][i] > 0 && s[j][j] > 0 {
+				rho += rbar / 2 * (math.Sqrt(s[j][j]/s[i][i])*thetaII/float64(n) +
+					math.Sqrt(s[i][i]/s[j][j])*thetaJJ/float64(n))
+			}
+
+			d := target[i][j] - s[i][j]
+			gamma += d * d
+		}
+	}
+
+	if gamma == 0 {
+		return 1
+	}
+	return clamp01((pi - rho) / gamma / float64(n))
+}
+
+// IdentityTarget is tr(S)/p on the diagonal and zero elsewhere.
+func IdentityTarget(cov [][]float64) [][]float64 {
+	p := len(cov)
+	mu := trace(cov) / float64(p)
+	out := square(p)
+	for i := range out {
+		out[i][i] = mu
+	}
+	return out
+}
+
+// ConstantCorrelationTarget keeps the variances of cov and replaces every
+// correlation with their average.
+func ConstantCorrelationTarget(cov [][]float64) [][]float64 {
+	out, _ := constantCorrelation(cov)
+	return out
+}
+
+// ShrinkTo returns delta·target + (1-delta)·cov.
+func ShrinkTo(cov, target [][]float64, delta float64) [][]float64 {
+	out := square(len(cov))
+	for i := range cov {
+		for j := range cov[i] {
+			out[i][j] = delta*target[i][j] + (1-delta)*cov[i][j]
+		}
+	}
+	return out
+}
+
+func constantCorrelation(s [][]float64) ([][]float64, float64) {
+	p := len(s)
+	sum, pairs := 0.0, 0
+	for i := 0; i < p; i++ {
+		for j := i + 1; j < p; j++ {
+			if s[i][i] > 0 && s[j][j] > 0 {
+				sum += s[i][j] / math.Sqrt(s[i][i]*s[j][j])
+				pairs++
+			}
+		}
+	}
+	rbar := 0.0
+	if pairs > 0 {
+		rbar = sum / float64(pairs)
+	}
+
+	out := square(p)
+	for i := 0; i < p; i++ {
+		out[i][i] = s[i][i]
+		for j := i + 1; j < p; j++ {
+			f := rbar * math.Sqrt(s[i][i]*s[j][j])
+			out[i][j] = f
+			out[j][i] = f
+		}
+	}
+	return out, rbar
+}
+
+// centered demeans each series and returns it with the maximum
+// likelihood covariance (divided by n), which the intensity formulas use.
+func centered(series [][]float64) ([][]float64, [][]float64) {
+	p, n := len(series), len(series[0])
+	x := make([][]float64, p)
+	for i, ser := range series {
+		mean := Mean(ser)
+		x[i] = make([]float64, n)
+		for t, v := range ser {
+			x[i][t] = v - mean
+		}
+	}
+
+	s := square(p)
+	for i := 0; i < p; i++ {
+		for j := i; j < p; j++ {
+			c := 0.0
+			for t := 0; t < n; t++ {
+				c += x[i][t] * x[j][t]
+			}
+			c /= float64(n)
+			s[i][j] = c
+			s[j][i] = c
+		}
+	}
+	return x, s
+}
+
+func trace(a [][]float64) float64 {
+	t := 0.0
+	for i := range a {
+		t += a[i][i]
+	}
+	return t
+}
+
+func frobenius2(a [][]float64) float64 {
+	sum := 0.0
+	for i := range a {
+		for _, v := range a[i] {
+			sum += v * v
+		}
+	}
+	return sum
+}
+
+func square(n int) [][]float64 {
+	out := make([][]float64, n)
+	for i := range out {
+		out[i] = make([]float64, n)
+	}
+	return out
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,132 @@
+package math
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// factorSeries draws n returns for p variables whose correlation is rho
+// within each of the given blocks and zero across them.
+func factorSeries(rng *rand.Rand, p, n, blocks int, rho float64) [][]float64 {
+	x := make([][]float64, p)
+	for i := range x {
+		x[i] = make([]float64, n)
+	}
+	for t := 0; t < n; t++ {
+		f := make([]float64, blocks)
+		for b := range f {
+			f[b] = rng.NormFloat64()
+		}
+		for i := range x {
+			x[i][t] = math.Sqrt(rho)*f[i%blocks] + math.Sqrt(1-rho)*rng.NormFloat64()
+		}
+	}
+	return x
+}
+
+// TestLedoitWolfDefinition checks the |x|⁴ shortcut against the
+// intensity computed straight from its definition.
+func TestLedoitWolfDefinition(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	series := factorSeries(rng, 6, 25, 2, 0.4)
+	x, s := centered(series)
+	p, n := len(x), len(x[0])
+
+	mu := trace(s) / float64(p)
+	delta, beta := 0.0, 0.0
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			d := s[i][j]
+			if i == j {
+				d -= mu
+			}
+			delta += d * d
+		}
+	}
+	for k := 0; k < n; k++ {
+		for i := 0; i < p; i++ {
+			for j := 0; j < p; j++ {
+				d := x[i][k]*x[j][k] - s[i][j]
+				beta += d * d
+			}
+		}
+	}
+	beta /= float64(n * n)
+	want := math.Min(beta, delta) / delta
+
+	if got := LedoitWolf(series); math.Abs(got-want) > 1e-12 {
+		t.Errorf("LedoitWolf = %v, want %v", got, want)
+	}
+}
+
+// TestOASClosedForm uses a sample whose covariance is exact in
+// fractions: S = [[2, 3/2, 25], [3/2, 189/8, 375/4], [25, 375/4, 1125/2]]
+// with n = 16, which gives δ = 769911/4233677.
+func TestOASClosedForm(t *testing.T) {
+	a := []float64{1, -1, 2, -2, 1, -1, 2, -2, 0, 0, 1, -1, 2, -2, 1, -1}
+	b := []float64{1, 1, -1, -1, 2, 2, -2, -2, 1, -1, 1, -1, 0, 0, 3, -3}
+	series := [][]float64{make([]float64, 16), make([]float64, 16), make([]float64, 16)}
+	for k := range a {
+		series[0][k] = a[k]
+		series[1][k] = 3 * b[k]
+		series[2][k] = 10 * (a[k] + b[k])
+	}
+
+	want := 769911.0 / 4233677.0
+	if got := OAS(series); math.Abs(got-want) > 1e-12 {
+		t.Errorf("OAS = %v, want %v", got, want)
+	}
+}
+
+// TestShrinkageIntensities checks that each intensity is high when its
+// target is the truth and the sample is short, and low when the target
+// is wrong and the sample long.
+func TestShrinkageIntensities(t *testing.T) {
+	rng := rand.New(rand.NewSource(12))
+
+	iid := factorSeries(rng, 10, 40, 1, 0)
+	one := factorSeries(rng, 10, 2000, 1, 0.7)
+	uniform := factorSeries(rng, 10, 40, 1, 0.5)
+	twoBlocks := factorSeries(rng, 10, 2000, 2, 0.8)
+
+	cases := []struct {
+		name   string
+		delta  float64
+		lo, hi float64
+	}{
+		{"LedoitWolf/identity truth", LedoitWolf(iid), 0.5, 1},
+		{"LedoitWolf/one factor", LedoitWolf(one), 0, 0.05},
+		{"OAS/identity truth", OAS(iid), 0.5, 1},
+		{"OAS/one factor", OAS(one), 0, 0.05},
+		{"ConstantCorrelation/uniform truth", ConstantCorrelation(uniform), 0.5, 1},
+		{"ConstantCorrelation/two blocks", ConstantCorrelation(twoBlocks), 0, 0.05},
+	}
+	for _, c := range cases {
+		if c.delta < c.lo || c.delta > c.hi {
+			t.Errorf("%s: δ = %.3f, want in [%v, %v]", c.name, c.delta, c.lo, c.hi)
+		}
+	}
+}
+
+func TestConstantCorrelationTarget(t *testing.T) {
+	cov := [][]float64{
+		{4, 2, 0},
+		{2, 1, 0.25},
+		{0, 0.25, 9},
+	}
+	// Correlations 1, 0 and 1/12 average to 13/36.
+	rbar := 13.0 / 36
+	got := ConstantCorrelationTarget(cov)
+	for i := range cov {
+		for j := range cov {
+			want := cov[i][i]
+			if i != j {
+				want = rbar * math.Sqrt(cov[i][i]*cov[j][j])
+			}
+			if math.Abs(got[i][j]-want) > 1e-12 {
+				t.Errorf("target[%d][%d] = %v, want %v", i, j, got[i][j], want)
+			}
+		}
+	}
+}
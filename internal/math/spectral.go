@@ -0,0 +1,48 @@
+package math
+
+import "math"
+
+// AbsorptionRatio is the share of total variance absorbed by the k
+// largest eigenvalues (Kritzman et al. 2011). vals must be sorted in
+// descending order. A high ratio means a few factors drive everything
+// and the market is tightly coupled.
+func AbsorptionRatio(vals []float64, k int) float64 {
+	if k > len(vals) {
+		k = len(vals)
+	}
+	top, total := 0.0, 0.0
+	for i, v := range vals {
+		v = math.Max(v, 0)
+		if i < k {
+			top += v
+		}
+		total += v
+	}
+	if total == 0 {
+		return 0
+	}
+	return top / total
+}
+
+// SpectralEntropy treats the eigenvalues as a distribution of variance
+// and returns its Shannon entropy normalized to [0, 1] by log n, together
+// with the effective rank exp(H): the number of equally sized factors
+// that would spread variance as evenly. Both fall as correlations
+// concentrate into fewer factors.
+func SpectralEntropy(vals []float64) (entropy, effectiveRank float64) {
+	total := 0.0
+	for _, v := range vals {
+		total += math.Max(v, 0)
+	}
+	if total == 0 || len(vals) < 2 {
+		return 0, 0
+	}
+
+	h := 0.0
+	for _, v := range vals {
+		if p := v / total; p > 0 {
+			h -= p * math.Log(p)
+		}
+	}
+	return h / math.Log(float64(len(vals))), math.Exp(h)
+}
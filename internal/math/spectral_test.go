@@ -0,0 +1,45 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAbsorptionRatio(t *testing.T) {
+	for _, tc := range []struct {
+		vals []float64
+		k    int
+		want float64
+	}{
+		{[]float64{2, 1.5, 0.3, 0.2}, 1, 0.5},
+		{[]float64{2, 1.5, 0.3, 0.2}, 2, 0.875},
+		{[]float64{2, 1.5, 0.3, 0.2}, 9, 1},
+		{[]float64{9, 1, 1, 1}, 1, 0.75},
+		// Rounding can leave tiny negative eigenvalues; they count as 0.
+		{[]float64{3, 1, -1e-17}, 1, 0.75},
+		{[]float64{0, 0}, 1, 0},
+	} {
+		if got := AbsorptionRatio(tc.vals, tc.k); math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("AbsorptionRatio(%v, %d) = %v, want %v", tc.vals, tc.k, got, tc.want)
+		}
+	}
+}
+
+func TestSpectralEntropy(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		vals          []float64
+		entropy, rank float64
+	}{
+		{"even", []float64{1, 1, 1, 1}, 1, 4},
+		{"one factor", []float64{4, 0, 0, 0}, 0, 1},
+		// Two equal factors: H = log 2, half of log 4.
+		{"two factors", []float64{2, 2, 0, 0}, 0.5, 2},
+		{"single symbol", []float64{1}, 0, 0},
+	} {
+		entropy, rank := SpectralEntropy(tc.vals)
+		if math.Abs(entropy-tc.entropy) > 1e-12 || math.Abs(rank-tc.rank) > 1e-12 {
+			t.Errorf("%s: SpectralEntropy = %v, %v, want %v, %v", tc.name, entropy, rank, tc.entropy, tc.rank)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Package network treats a correlation matrix as a weighted graph of
+// symbols and measures its shape.
+package network
+
+import (
+	"math"
+
+	"matrixpulse/internal/types"
+)
+
+// Distance is Mantegna's metric √(2(1-ρ)).
+func Distance(rho float64) float64 {
+	return math.Sqrt(2 * math.Max(1-rho, 0))
+}
+
+// MST returns the n-1 edges of the minimum spanning tree of the complete
+// graph on cor, weighted by Distance, using Prim's algorithm. Strongly
+// correlated pairs are short edges, so the tree keeps each symbol's most
+// important link.
+func MST(cor [][]float64) []types.Edge {
+	n := len(cor)
+	if n < 2 {
+		return nil
+	}
+
+	in := make([]bool, n)
+	best := make([]float64, n)
+	from := make([]int, n)
+	for i := range best {
+		best[i] = math.Inf(1)
+	}
+	best[0] = 0
+
+	edges := make([]types.Edge, 0, n-1)
+	for step := 0; step < n; step++ {
+		u := -1
+		for v := 0; v < n; v++ {
+			if !in[v] && (u < 0 || best[v] < best[u]) {
+				u = v
+			}
+		}
+		in[u] = true
+		if step > 0 {
+			edges = append(edges, edge(cor, from[u], u))
+		}
+		for v := 0; v < n; v++ {
+			if !in[v] {
+				if d := Distance(cor[u][v]); d < best[v] {
+					best[v] = d
+					from[v] = u
+				}
+			}
+		}
+	}
+	return edges
+}
+
+// Threshold returns every pair whose absolute correlation is at least
+// t.
+func Threshold(cor [][]float64, t float64) []types.Edge {
+	var edges []types.Edge
+	for i := range cor {
+		for j := i + 1; j < len(cor); j++ {
+			if math.Abs(cor[i][j]) >= t {
+				edges = append(edges, edge(cor, i, j))
+			}
+		}
+	}
+	return edges
+}
+
+func edge(cor [][]float64, a, b int) types.Edge {
+	if a > b {
+		a, b = b, a
+	}
+	return types.Edge{A: a, B: b, Cor: cor[a][b], Distance: Distance(cor[a][b])}
+}
+
+// Length is the total distance of the edges. For the MST, a shrinking
+// length means the market is contracting around fewer, tighter links,
+// as it does in a crisis.
+func Length(edges []types.Edge) float64 {
+	sum := 0.0
+	for _, e := range edges {
+		sum += e.Distance
+	}
+	return sum
+}
+
+// Degree counts the edges at each of n nodes.
+func Degree(edges []types.Edge, n int) []int {
+	deg := make([]int, n)
+	for _, e := range edges {
+		deg[e.A]++
+		deg[e.B]++
+	}
+	return deg
+}
+
+// Betweenness is the share of shortest paths between other pairs of
+// nodes that pass through each node, counting hops (Brandes 2001). It is
+// normalized to [0, 1] by the number of pairs.
+func Betweenness(edges []types.Edge, n int) []float64 {
+	adj := adjacency(edges, n)
+	bc := make([]float64, n)
+
+	stack := make([]int, 0, n)
+	queue := make([]int, 0, n)
+	preds := make([][]int, n)
+	sigma := make([]float64, n)
+	dist := make([]int, n)
+	delta := make([]float64, n)
+
+	for s := 0; s < n; s++ {
+		stack = stack[:0]
+		queue = append(queue[:0], s)
+		for i := range preds {
+			preds[i] = preds[i][:0]
+			sigma[i] = 0
+			dist[i] = -1
+			delta[i] = 0
+		}
+		sigma[s] = 1
+		dist[s] = 0
+
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for _, w := range adj[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+
+		for len(stack) > 0 {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				bc[w] += delta[w]
+			}
+		}
+	}
+
+	// Each pair was counted from both ends.
+	if pairs := float64(n-1) * float64(n-2); pairs > 0 {
+		for i := range bc {
+			bc[i] /= pairs
+		}
+	}
+	return bc
+}
+
+// Eigenvector gives each node a score proportional to the sum of its
+// neighbours' scores, weighting edges by absolute correlation, scaled so
+// the most central node scores 1. Power iteration runs on A+I, which has
+// the same leading eigenvector as A but also converges on trees and
+// other bipartite graphs.
+func Eigenvector(edges []types.Edge, n int) []float64 {
+	x := make([]float64, n)
+	if len(edges) == 0 {
+		return x
+	}
+	for i := range x {
+		x[i] = 1
+	}
+
+	next := make([]float64, n)
+	for iter := 0; iter < 200; iter++ {
+		copy(next, x)
+		for _, e := range edges {
+			w := math.Abs(e.Cor)
+			next[e.A] += w * x[e.B]
+			next[e.B] += w * x[e.A]
+		}
+
+		peak := 0.0
+		for _, v := range next {
+			peak = math.Max(peak, v)
+		}
+		change := 0.0
+		for i := range next {
+			next[i] /= peak
+			change = math.Max(change, math.Abs(next[i]-x[i]))
+		}
+		x, next = next, x
+		if change < 1e-9 {
+			break
+		}
+	}
+	return x
+}
+
+func adjacency(edges []types.Edge, n int) [][]int {
+	adj := make([][]int, n)
+	for _, e := range edges {
+		adj[e.A] = append(adj[e.A], e.B)
+		adj[e.B] = append(adj[e.B], e.A)
+	}
+	return adj
+}
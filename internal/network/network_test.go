@@ -0,0 +1,181 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"matrixpulse/internal/types"
+)
+
+// hops returns BFS distances and shortest-path counts from s.
+func hops(adj [][]int, s int) ([]int, []float64) {
+	dist := make([]int, len(adj))
+	sigma := make([]float64, len(adj))
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[s], sigma[s] = 0, 1
+	queue := []int{s}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range adj[v] {
+			if dist[w] < 0 {
+				dist[w] = dist[v] + 1
+				queue = append(queue, w)
+			}
+			if dist[w] == dist[v]+1 {
+				sigma[w] += sigma[v]
+			}
+		}
+	}
+	return dist, sigma
+}
+
+// naiveBetweenness sums, over every pair s, t of other nodes, the share
+// of s–t shortest paths through v: σ(s,v)σ(v,t)/σ(s,t) when v lies on
+// one.
+func naiveBetweenness(edges []types.Edge, n int) []float64 {
+	adj := adjacency(edges, n)
+	dist := make([][]int, n)
+	sigma := make([][]float64, n)
+	for s := range dist {
+		dist[s], sigma[s] = hops(adj, s)
+	}
+
+	bc := make([]float64, n)
+	for v := 0; v < n; v++ {
+		for s := 0; s < n; s++ {
+			for t := s + 1; t < n; t++ {
+				if s == v || t == v || dist[s][t] < 0 || dist[s][v] < 0 || dist[v][t] < 0 {
+					continue
+				}
+				if dist[s][v]+dist[v][t] == dist[s][t] {
+					bc[v] += sigma[s][v] * sigma[v][t] / sigma[s][t]
+				}
+			}
+		}
+	}
+	if n > 2 {
+		for i := range bc {
+			bc[i] /= float64(n-1) * float64(n-2) / 2
+		}
+	}
+	return bc
+}
+
+func TestBetweennessKnownGraphs(t *testing.T) {
+	star := []types.Edge{{A: 0, B: 1}, {A: 0, B: 2}, {A: 0, B: 3}, {A: 0, B: 4}}
+	path := []types.Edge{{A: 0, B: 1}, {A: 1, B: 2}, {A: 2, B: 3}, {A: 3, B: 4}}
+	for _, tc := range []struct {
+		name  string
+		edges []types.Edge
+		want  []float64
+	}{
+		// Every pair of leaves routes through the hub.
+		{"star", star, []float64{1, 0, 0, 0, 0}},
+		// Node 1 separates {0} from {2,3,4}: 3 of the 6 pairs; node 2
+		// separates {0,1} from {3,4}: 4 of 6.
+		{"path", path, []float64{0, 0.5, 4.0 / 6, 0.5, 0}},
+		// A 4-cycle: each node carries half of the one opposite pair.
+		{"cycle", []types.Edge{{A: 0, B: 1}, {A: 1, B: 2}, {A: 2, B: 3}, {A: 0, B: 3}}, []float64{1.0 / 6, 1.0 / 6, 1.0 / 6, 1.0 / 6}},
+	} {
+		got := Betweenness(tc.edges, len(tc.want))
+		for i := range tc.want {
+			if math.Abs(got[i]-tc.want[i]) > 1e-12 {
+				t.Errorf("%s: Betweenness = %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestBetweennessMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(17))
+	for trial := 0; trial < 100; trial++ {
+		n := 1 + rng.Intn(12)
+		p := rng.Float64() // sparse graphs are often disconnected
+		var edges []types.Edge
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if rng.Float64() < p {
+					edges = append(edges, types.Edge{A: i, B: j})
+				}
+			}
+		}
+
+		got, want := Betweenness(edges, n), naiveBetweenness(edges, n)
+		for i := range want {
+			if math.Abs(got[i]-want[i]) > 1e-12 {
+				t.Fatalf("trial %d: Betweenness = %v, naive = %v\nedges = %v", trial, got, want, edges)
+			}
+		}
+	}
+}
+
+// kruskalLength is the MST length found by Kruskal's algorithm, an
+// independent check on Prim's in MST.
+func kruskalLength(cor [][]float64) float64 {
+	n := len(cor)
+	var edges []types.Edge
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			edges = append(edges, edge(cor, i, j))
+		}
+	}
+	sort.Slice(edges, func(a, b int) bool { return edges[a].Distance < edges[b].Distance })
+
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(x int) int {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+	sum := 0.0
+	for _, e := range edges {
+		if a, b := find(e.A), find(e.B); a != b {
+			parent[a] = b
+			sum += e.Distance
+		}
+	}
+	return sum
+}
+
+func TestMSTSpansWithMinimalLength(t *testing.T) {
+	rng := rand.New(rand.NewSource(19))
+	for trial := 0; trial < 50; trial++ {
+		n := 2 + rng.Intn(15)
+		cor := make([][]float64, n)
+		for i := range cor {
+			cor[i] = make([]float64, n)
+			cor[i][i] = 1
+		}
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				cor[i][j] = 2*rng.Float64() - 1
+				cor[j][i] = cor[i][j]
+			}
+		}
+
+		edges := MST(cor)
+		if len(edges) != n-1 {
+			t.Fatalf("trial %d: %d edges for %d nodes", trial, len(edges), n)
+		}
+		dist, _ := hops(adjacency(edges, n), 0)
+		for v, d := range dist {
+			if d < 0 {
+				t.Fatalf("trial %d: node %d not reached by the tree %v", trial, v, edges)
+			}
+		}
+		if got, want := Length(edges), kruskalLength(cor); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("trial %d: MST length %v, Kruskal %v", trial, got, want)
+		}
+	}
+}
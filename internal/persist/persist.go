@@ -18,13 +18,21 @@ func New(path string, eng *engine.Engine) *Persister {
 
 func (p *Persister) Save() error {
 	data := struct {
-		Matrix interface{} `json:"matrix"`
-		Mode   interface{} `json:"mode"`
-		Alerts interface{} `json:"alerts"`
+		Matrix   interface{} `json:"matrix"`
+		Mode     interface{} `json:"mode"`
+		Alerts   interface{} `json:"alerts"`
+		Stats    interface{} `json:"stats"`
+		Clusters interface{} `json:"clusters"`
+		Network  interface{} `json:"network"`
+		LeadLag  interface{} `json:"lead_lag"`
 	}{
-		Matrix: p.eng.Matrix(),
-		Mode:   p.eng.Mode(),
-		Alerts: p.eng.Alerts(),
+		Matrix:   p.eng.Matrix(),
+		Mode:     p.eng.Mode(),
+		Alerts:   p.eng.Alerts(),
+		Stats:    p.eng.Stats(),
+		Clusters: p.eng.Clusters(),
+		Network:  p.eng.Network(),
+		LeadLag:  p.eng.LeadLag(),
 	}
 
 	f, err := os.Create(p.path)
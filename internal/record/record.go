@@ -0,0 +1,238 @@
+package record
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+// Session files start with magic followed by length-prefixed records:
+//
+//	uvarint payload length
+//	uvarint symbol length, symbol bytes
+//	float64 price, float64 volume (little-endian IEEE 754 bits)
+//	varint  unix nanoseconds
+//	float64 open, high, low (bar fields, 0 for plain ticks)
+//	varint  unix nanoseconds the tick arrived at
+//
+// Earlier development builds wrote other layouts under MPSESS1 to
+// MPSESS3; the magic moved on so those files are rejected, not misread.
+const magic = "MPSESS4\n"
+
+// maxRecord bounds a record's payload length. Real records are a few
+// dozen bytes; anything larger means a garbled length prefix.
+const maxRecord = 64 << 10
+
+// Ext is the file extension used for session files.
+const Ext = ".mps"
+
+// Recorder writes ticks to session files in a directory, starting a new
+// file whenever the current one exceeds the configured size or age.
+type Recorder struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+
+	f       *os.File
+	w       *bufio.Writer
+	size    int64
+	opened  time.Time
+	scratch []byte
+}
+
+func New(cfg config.Recording) (*Recorder, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording dir: %w", err)
+	}
+
+	return &Recorder{
+		dir:      cfg.Dir,
+		maxBytes: int64(cfg.MaxMB) << 20,
+		maxAge:   time.Duration(cfg.MaxMinutes) * time.Minute,
+	}, nil
+}
+
+// Write appends a tick that arrived at the given time to the current
+// session file, rotating first if the file is full or too old. Replay is
+// paced on the arrival times, so it reproduces what the engine saw even
+// when the feed's own timestamps run at a different rate.
+func (r *Recorder) Write(t types.Tick, arrived time.Time) error {
+	if r.f == nil || r.size >= r.maxBytes || time.Since(r.opened) >= r.maxAge {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+
+	payload := encode(r.scratch[:0], t, arrived)
+	var prefix [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(prefix[:], uint64(len(payload)))
+
+	if _, err := r.w.Write(prefix[:n]); err != nil {
+		return err
+	}
+	if _, err := r.w.Write(payload); err != nil {
+		return err
+	}
+	r.size += int64(n + len(payload))
+	r.scratch = payload
+	return nil
+}
+
+// Flush pushes buffered records to the current file.
+func (r *Recorder) Flush() error {
+	if r.w == nil {
+		return nil
+	}
+	return r.w.Flush()
+}
+
+// Close flushes and closes the current file.
+func (r *Recorder) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.w.Flush()
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	r.f, r.w = nil, nil
+	return err
+}
+
+func (r *Recorder) rotate() error {
+	if err := r.Close(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := "session-" + now.UTC().Format("20060102-150405.000") + Ext
+	f, err := os.Create(filepath.Join(r.dir, name))
+	if err != nil {
+		return fmt.Errorf("failed to create session file: %w", err)
+	}
+
+	r.f = f
+	r.w = bufio.NewWriterSize(f, 64<<10)
+	r.opened = now
+	r.size = 0
+
+	n, err := r.w.WriteString(magic)
+	r.size += int64(n)
+	return err
+}
+
+func encode(buf []byte, t types.Tick, arrived time.Time) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(t.Symbol)))
+	buf = append(buf, t.Symbol...)
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(t.Price))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(t.Volume))
+	buf = binary.AppendVarint(buf, t.Time.UnixNano())
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(t.Open))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(t.High))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(t.Low))
+	buf = binary.AppendVarint(buf, arrived.UnixNano())
+	return buf
+}
+
+var errCorrupt = errors.New("corrupt session record")
+
+// decode parses one record payload.
+func decode(buf []byte) (t types.Tick, arrived time.Time, err error) {
+	n, k := binary.Uvarint(buf)
+	if k <= 0 || uint64(len(buf)-k) < n {
+		return t, arrived, errCorrupt
+	}
+	buf = buf[k:]
+	t.Symbol = string(buf[:n])
+	buf = buf[n:]
+
+	if len(buf) < 16 {
+		return t, arrived, errCorrupt
+	}
+	t.Price = math.Float64frombits(binary.LittleEndian.Uint64(buf))
+	t.Volume = math.Float64frombits(binary.LittleEndian.Uint64(buf[8:]))
+	buf = buf[16:]
+
+	ns, k := binary.Varint(buf)
+	if k <= 0 {
+		return t, arrived, errCorrupt
+	}
+	t.Time = time.Unix(0, ns)
+	buf = buf[k:]
+
+	if len(buf) < 24 {
+		return t, arrived, errCorrupt
+	}
+	t.Open = math.Float64frombits(binary.LittleEndian.Uint64(buf))
+	t.High = math.Float64frombits(binary.LittleEndian.Uint64(buf[8:]))
+	t.Low = math.Float64frombits(binary.LittleEndian.Uint64(buf[16:]))
+	buf = buf[24:]
+
+	ns, k = binary.Varint(buf)
+	if k <= 0 {
+		return t, arrived, errCorrupt
+	}
+	return t, time.Unix(0, ns), nil
+}
+
+// Reader reads ticks back from a session file.
+type Reader struct {
+	f   *os.File
+	r   *bufio.Reader
+	buf []byte
+}
+
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReaderSize(f, 64<<10)
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, head); err != nil || string(head) != magic {
+		f.Close()
+		return nil, fmt.Errorf("%s is not a session file", path)
+	}
+
+	return &Reader{f: f, r: r}, nil
+}
+
+// Next returns the next tick and the time it arrived, or io.EOF at the
+// end of the file. A record cut short by an unclean shutdown yields
+// io.ErrUnexpectedEOF.
+func (r *Reader) Next() (types.Tick, time.Time, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if err == io.EOF {
+		return types.Tick{}, time.Time{}, io.EOF
+	}
+	if err != nil {
+		return types.Tick{}, time.Time{}, io.ErrUnexpectedEOF
+	}
+	if n > maxRecord {
+		return types.Tick{}, time.Time{}, errCorrupt
+	}
+
+	if uint64(cap(r.buf)) < n {
+		r.buf = make([]byte, n)
+	}
+	r.buf = r.buf[:n]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return types.Tick{}, time.Time{}, io.ErrUnexpectedEOF
+	}
+
+	return decode(r.buf)
+}
+
+func (r *Reader) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,104 @@
+package record
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := New(config.Recording{Dir: dir, MaxMB: 1, MaxMinutes: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Unix(1700000000, 123456789)
+	want := []types.Tick{
+		{Symbol: "AAPL", Price: 189.5, Volume: 300, Time: at},
+		{Symbol: "ESZ4", Price: 5000.25, Volume: 1, Time: at.Add(time.Millisecond), Open: 4990, High: 5010.5, Low: 4985.75},
+	}
+	// Ticks stamped by the vendor a second apart arrived 5 ms apart.
+	arrived := []time.Time{at.Add(time.Hour), at.Add(time.Hour + 5*time.Millisecond)}
+	want[1].Time = at.Add(time.Second)
+	for i, tk := range want {
+		if err := rec.Write(tk, arrived[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := openOnly(t, dir)
+	defer r.Close()
+	for i, w := range want {
+		got, at, err := r.Next()
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if got.Symbol != w.Symbol || got.Price != w.Price || got.Volume != w.Volume || !got.Time.Equal(w.Time) ||
+			got.Open != w.Open || got.High != w.High || got.Low != w.Low {
+			t.Errorf("record %d = %+v, want %+v", i, got, w)
+		}
+		if !at.Equal(arrived[i]) {
+			t.Errorf("record %d arrived %v, want %v", i, at, arrived[i])
+		}
+	}
+	if _, _, err := r.Next(); err != io.EOF {
+		t.Errorf("after last record: %v, want io.EOF", err)
+	}
+}
+
+// TestOpenRejectsOldMagic checks that files from development builds,
+// whose layouts differ, are refused rather than misread.
+func TestOpenRejectsOldMagic(t *testing.T) {
+	for _, old := range []string{"MPSESS1\n", "MPSESS2\n", "MPSESS3\n"} {
+		path := filepath.Join(t.TempDir(), "old"+Ext)
+		if err := os.WriteFile(path, []byte(old), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if r, err := Open(path); err == nil {
+			r.Close()
+			t.Errorf("Open accepted a file starting %q", old)
+		}
+	}
+}
+
+func TestNextRejectsOversizedRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad"+Ext)
+	buf := []byte(magic)
+	buf = binary.AppendUvarint(buf, 1<<62)
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, _, err := r.Next(); !errors.Is(err, errCorrupt) {
+		t.Errorf("Next = %v, want errCorrupt", err)
+	}
+}
+
+func openOnly(t *testing.T, dir string) *Reader {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+Ext))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("want one session file, got %v (%v)", files, err)
+	}
+	r, err := Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
@@ -7,21 +7,122 @@ type Tick struct {
 	Price  float64
 	Volume float64
 	Time   time.Time
+	// Bar fields, set by feeds that deliver OHLC bars; Price is the
+	// close. They are zero for plain ticks.
+	Open float64
+	High float64
+	Low  float64
+}
+
+// SymbolStats is per-symbol volatility over the current window. The
+// annualized figures are 0 when timestamps or bars are unavailable.
+type SymbolStats struct {
+	Symbol      string
+	Price       float64
+	Volatility  float64 // standard deviation of log returns per sample
+	AnnualVol   float64 // close-to-close, annualized
+	Parkinson   float64 // high-low range estimator, annualized
+	GarmanKlass float64 // open-high-low-close estimator, annualized
+	Time        time.Time
 }
 
 type Matrix struct {
-	Cov     [][]float64
-	Cor     [][]float64
-	Symbols []string
-	Time    time.Time
+	Cov       [][]float64
+	Cor       [][]float64
+	Method    string                 // correlation measure in Cor
+	Alt       map[string][][]float64 // other measures computed alongside
+	Shrinkage float64                // intensity pulling Cov and Cor to their targets, 0 when off
+	Cleaned   [][]float64            // Cor with noise eigenvalues clipped, nil when off
+	Precision [][]float64            // inverse covariance, nil when off
+	Partial   [][]float64            // partial correlations from Precision
+	Symbols   []string
+	Time      time.Time
 }
 
 type Mode struct {
-	Eigenvalues []float64
-	MaxEigen    float64
-	Condition   float64
-	Regime      string
-	Time        time.Time
+	Eigenvalues  []float64   // descending
+	Eigenvectors [][]float64 // Eigenvectors[k] belongs to Eigenvalues[k]
+	Explained    []float64   // share of total variance per eigenvalue
+	Loadings     [][]float64 // Loadings[i][k]: symbol i on component k, top components only
+	MaxEigen     float64
+	Condition    float64
+	Regime       string
+	NoiseBound   float64 // Marchenko–Pastur upper edge, 0 when RMT is off
+	Signals      int     // eigenvalues above NoiseBound
+
+	AbsorptionRatio float64 // variance share of the top eigenvalues
+	AbsorptionShift float64 // recent ratio vs its baseline, in standard deviations
+	Entropy         float64 // spectral entropy normalized to 0-1
+	EffectiveRank   float64 // exp of the spectral entropy
+
+	Time time.Time
+}
+
+// Merge is one step of a hierarchical clustering. Left and Right are node
+// ids: ids below the number of symbols are symbols, and merge k creates
+// node len(symbols)+k.
+type Merge struct {
+	Left   int
+	Right  int
+	Height float64
+	Size   int
+}
+
+// Clustering is a hierarchical clustering of the symbols.
+type Clustering struct {
+	Linkage string
+	Merges  []Merge // the dendrogram
+	Labels  []int   // flat cluster of each symbol at the configured cut
+	Count   int     // number of flat clusters
+	Order   []int   // symbol indexes in quasi-diagonal order
+	Time    time.Time
+}
+
+// Edge links two symbols, by index, in a correlation network.
+type Edge struct {
+	A        int
+	B        int
+	Cor      float64
+	Distance float64 // √(2(1-Cor))
+}
+
+// Network describes the correlation network: its minimum spanning tree,
+// the graph of pairs above the correlation threshold, and per-symbol
+// centrality measured on the tree and compared with its recent history.
+type Network struct {
+	Tree       []Edge
+	Threshold  []Edge
+	Length     float64 // total tree distance
+	MeanLength float64 // Length / number of tree edges
+	LengthZ    float64 // MeanLength vs its recent history, in standard deviations
+	Nodes      []NodeStats
+	Hub        string // symbol with the highest betweenness
+	Time       time.Time
+}
+
+type NodeStats struct {
+	Symbol          string
+	Degree          int     // tree edges
+	ThresholdDegree int     // threshold graph edges
+	Betweenness     float64 // share of tree paths through the symbol
+	Eigenvector     float64 // eigenvector centrality on the tree, max 1
+
+	// Betweenness and Degree against their recent history, in standard
+	// deviations; 0 until the history varies.
+	BetweennessZ float64
+	DegreeZ      float64
+}
+
+// LeadLag is a pair's strongest lagged correlation. Leader's returns
+// line up best with Follower's Lag samples later; Lag 0 means neither
+// leads.
+type LeadLag struct {
+	Leader   string
+	Follower string
+	Lag      int
+	Cor      float64 // correlation at Lag
+	ZeroLag  float64 // contemporaneous correlation
+	Stable   int     // consecutive updates with this lead above the alert threshold
 }
 
 type Alert struct {
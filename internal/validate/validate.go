@@ -0,0 +1,200 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+	"matrixpulse/internal/window"
+)
+
+// Rejection reasons, used as keys in Stats.Rejected.
+const (
+	InvalidPrice  = "invalid_price"
+	InvalidVolume = "invalid_volume"
+	Jump          = "jump"
+	Stale         = "stale"
+	Future        = "future"
+	Duplicate     = "duplicate"
+)
+
+// Validator screens ticks before they reach the engine, counting and
+// optionally quarantining the ones it rejects.
+//
+// The jump check compares each price to the median of the symbol's recent
+// prices. Prices rejected as jumps still enter that history, so a single
+// bad print is filtered while a genuine level shift is accepted once it
+// has persisted for half the median window.
+type Validator struct {
+	cfg     config.Validation
+	maxJump float64
+	maxAge  time.Duration
+	maxLead time.Duration
+
+	mu         sync.Mutex
+	history    map[string]*window.Rolling
+	last       map[string]types.Tick
+	accepted   uint64
+	rejected   map[string]uint64
+	quarantine *os.File
+	enc        *json.Encoder
+	lost       uint64
+}
+
+// minHistory is how many recent prices the jump check needs before it
+// starts rejecting.
+const minHistory = 3
+
+// Stats is a snapshot of validation counters. QuarantineErrors counts
+// rejected ticks that could not be written to the quarantine log.
+type Stats struct {
+	Accepted         uint64
+	Rejected         map[string]uint64
+	QuarantineErrors uint64
+}
+
+func New(cfg config.Validation) (*Validator, error) {
+	v := &Validator{
+		cfg:      cfg,
+		maxJump:  cfg.MaxJumpPct / 100,
+		maxAge:   time.Duration(cfg.MaxAgeMs) * time.Millisecond,
+		maxLead:  time.Duration(cfg.MaxFutureMs) * time.Millisecond,
+		history:  make(map[string]*window.Rolling),
+		last:     make(map[string]types.Tick),
+		rejected: make(map[string]uint64),
+	}
+
+	if cfg.QuarantinePath != "" {
+		f, err := os.OpenFile(cfg.QuarantinePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open quarantine log: %w", err)
+		}
+		v.quarantine = f
+		v.enc = json.NewEncoder(f)
+	}
+
+	return v, nil
+}
+
+// Check reports whether t should be ingested.
+func (v *Validator) Check(t types.Tick) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	reason := v.check(t)
+	if reason == "" {
+		v.accepted++
+		v.last[t.Symbol] = t
+		return true
+	}
+
+	v.rejected[reason]++
+	if v.enc != nil {
+		// Prices are written as strings since JSON has no NaN or Inf.
+		err := v.enc.Encode(struct {
+			Reason string    `json:"reason"`
+			Symbol string    `json:"symbol"`
+			Price  string    `json:"price"`
+			Volume string    `json:"volume"`
+			Time   time.Time `json:"time"`
+			At     time.Time `json:"at"`
+		}{
+			Reason: reason,
+			Symbol: t.Symbol,
+			Price:  strconv.FormatFloat(t.Price, 'g', -1, 64),
+			Volume: strconv.FormatFloat(t.Volume, 'g', -1, 64),
+			Time:   t.Time,
+			At:     time.Now(),
+		})
+		if err != nil {
+			v.lost++
+		}
+	}
+	return false
+}
+
+func (v *Validator) check(t types.Tick) string {
+	if t.Price <= 0 || math.IsNaN(t.Price) || math.IsInf(t.Price, 0) {
+		return InvalidPrice
+	}
+	if t.Volume < 0 || math.IsNaN(t.Volume) || math.IsInf(t.Volume, 0) {
+		return InvalidVolume
+	}
+
+	if v.maxAge > 0 || v.maxLead > 0 {
+		lag := time.Since(t.Time)
+		if v.maxAge > 0 && lag > v.maxAge {
+			return Stale
+		}
+		if v.maxLead > 0 && -lag > v.maxLead {
+			return Future
+		}
+	}
+
+	if v.cfg.RejectDuplicates {
+		if prev, ok := v.last[t.Symbol]; ok && prev.Time.Equal(t.Time) && prev.Price == t.Price && prev.Volume == t.Volume {
+			return Duplicate
+		}
+	}
+
+	if v.maxJump > 0 {
+		h, ok := v.history[t.Symbol]
+		if !ok {
+			h = window.New(v.cfg.MedianWindow)
+			v.history[t.Symbol] = h
+		}
+		recent := h.Snapshot()
+		h.Push(t.Price)
+
+		if len(recent) >= minHistory {
+			med := median(recent)
+			if math.Abs(t.Price/med-1) > v.maxJump {
+				return Jump
+			}
+		}
+	}
+
+	return ""
+}
+
+// Stats returns the counters accumulated so far.
+func (v *Validator) Stats() Stats {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s := Stats{
+		Accepted:         v.accepted,
+		Rejected:         make(map[string]uint64, len(v.rejected)),
+		QuarantineErrors: v.lost,
+	}
+	for k, n := range v.rejected {
+		s.Rejected[k] = n
+	}
+	return s
+}
+
+// Close closes the quarantine log.
+func (v *Validator) Close() error {
+	if v.quarantine == nil {
+		return nil
+	}
+	return v.quarantine.Close()
+}
+
+func median(data []float64) float64 {
+	sorted := append([]float64(nil), data...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
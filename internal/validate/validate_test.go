@@ -0,0 +1,147 @@
+package validate
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"matrixpulse/internal/config"
+	"matrixpulse/internal/types"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	steady := func(n int) []types.Tick {
+		ticks := make([]types.Tick, n)
+		for k := range ticks {
+			ticks[k] = types.Tick{Symbol: "AAPL", Price: 100 + float64(k%3), Time: now.Add(time.Duration(k-n) * time.Millisecond)}
+		}
+		return ticks
+	}
+
+	for _, tc := range []struct {
+		name string
+		cfg  config.Validation
+		pre  []types.Tick // accepted first to build up history
+		tick types.Tick
+		want string // rejection reason, "" to accept
+	}{
+		{"zero price", config.Validation{}, nil,
+			types.Tick{Symbol: "AAPL", Time: now}, InvalidPrice},
+		{"NaN price", config.Validation{}, nil,
+			types.Tick{Symbol: "AAPL", Price: math.NaN(), Time: now}, InvalidPrice},
+		{"negative volume", config.Validation{}, nil,
+			types.Tick{Symbol: "AAPL", Price: 100, Volume: -1, Time: now}, InvalidVolume},
+
+		{"jump", config.Validation{MaxJumpPct: 10, MedianWindow: 5}, steady(5),
+			types.Tick{Symbol: "AAPL", Price: 125, Time: now}, Jump},
+		{"move within limit", config.Validation{MaxJumpPct: 10, MedianWindow: 5}, steady(5),
+			types.Tick{Symbol: "AAPL", Price: 108, Time: now}, ""},
+		{"jump filter off", config.Validation{MedianWindow: 5}, steady(5),
+			types.Tick{Symbol: "AAPL", Price: 125, Time: now}, ""},
+		{"other symbol's history", config.Validation{MaxJumpPct: 10, MedianWindow: 5}, steady(5),
+			types.Tick{Symbol: "MSFT", Price: 400, Time: now}, ""},
+		// With two prices held there is no median yet, so anything goes.
+		{"warm-up", config.Validation{MaxJumpPct: 10, MedianWindow: 5}, steady(2),
+			types.Tick{Symbol: "AAPL", Price: 500, Time: now}, ""},
+		{"first checked", config.Validation{MaxJumpPct: 10, MedianWindow: 5}, steady(3),
+			types.Tick{Symbol: "AAPL", Price: 500, Time: now}, Jump},
+
+		{"stale", config.Validation{MaxAgeMs: 1000}, nil,
+			types.Tick{Symbol: "AAPL", Price: 100, Time: now.Add(-2 * time.Second)}, Stale},
+		{"recent enough", config.Validation{MaxAgeMs: 1000}, nil,
+			types.Tick{Symbol: "AAPL", Price: 100, Time: now.Add(-500 * time.Millisecond)}, ""},
+		{"future", config.Validation{MaxFutureMs: 1000}, nil,
+			types.Tick{Symbol: "AAPL", Price: 100, Time: now.Add(time.Minute)}, Future},
+		{"slightly ahead", config.Validation{MaxFutureMs: 60000}, nil,
+			types.Tick{Symbol: "AAPL", Price: 100, Time: now.Add(time.Second)}, ""},
+
+		{"duplicate", config.Validation{RejectDuplicates: true},
+			[]types.Tick{{Symbol: "AAPL", Price: 100, Volume: 5, Time: now}},
+			types.Tick{Symbol: "AAPL", Price: 100, Volume: 5, Time: now}, Duplicate},
+		{"same price, new time", config.Validation{RejectDuplicates: true},
+			[]types.Tick{{Symbol: "AAPL", Price: 100, Volume: 5, Time: now}},
+			types.Tick{Symbol: "AAPL", Price: 100, Volume: 5, Time: now.Add(time.Millisecond)}, ""},
+		{"duplicates allowed", config.Validation{},
+			[]types.Tick{{Symbol: "AAPL", Price: 100, Volume: 5, Time: now}},
+			types.Tick{Symbol: "AAPL", Price: 100, Volume: 5, Time: now}, ""},
+	} {
+		v, err := New(tc.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range tc.pre {
+			if !v.Check(p) {
+				t.Fatalf("%s: setup tick %+v rejected", tc.name, p)
+			}
+		}
+
+		accepted := v.Check(tc.tick)
+		st := v.Stats()
+		if accepted != (tc.want == "") || (tc.want != "" && st.Rejected[tc.want] != 1) {
+			t.Errorf("%s: accepted = %v, rejected %v, want reason %q", tc.name, accepted, st.Rejected, tc.want)
+		}
+		want := uint64(len(tc.pre))
+		if accepted {
+			want++
+		}
+		if st.Accepted != want {
+			t.Errorf("%s: %d accepted, want %d", tc.name, st.Accepted, want)
+		}
+	}
+}
+
+// TestJumpLevelShift checks that a lasting move is let through once it
+// fills half the median window, while a lone bad print is not.
+func TestJumpLevelShift(t *testing.T) {
+	v, err := New(config.Validation{MaxJumpPct: 10, MedianWindow: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(sym string, price float64) bool {
+		return v.Check(types.Tick{Symbol: sym, Price: price})
+	}
+	for k := 0; k < 5; k++ {
+		check("TSLA", 200)
+		check("AAPL", 100)
+	}
+
+	if check("AAPL", 10) {
+		t.Error("lone bad print accepted")
+	}
+	if !check("AAPL", 100) {
+		t.Error("normal price after a bad print rejected")
+	}
+
+	// A 20% crash is rejected until it makes up the median of the last
+	// five prices.
+	var got []bool
+	for k := 0; k < 4; k++ {
+		got = append(got, check("TSLA", 160))
+	}
+	if want := []bool{false, false, false, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("crash accepted = %v, want %v", got, want)
+	}
+}
+
+func TestQuarantineWriteErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rejected.jsonl")
+	v, err := New(config.Validation{QuarantinePath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Check(types.Tick{Symbol: "AAPL", Price: -1})
+	if st := v.Stats(); st.QuarantineErrors != 0 {
+		t.Fatalf("QuarantineErrors = %d before any failure", st.QuarantineErrors)
+	}
+
+	// Writes to a closed log fail, as they would on a full disk.
+	v.Close()
+	v.Check(types.Tick{Symbol: "AAPL", Price: -1})
+	v.Check(types.Tick{Symbol: "AAPL", Price: 0})
+	if st := v.Stats(); st.QuarantineErrors != 2 || st.Rejected[InvalidPrice] != 3 {
+		t.Errorf("stats = %+v, want 2 quarantine errors and 3 rejections", st)
+	}
+}
@@ -1,26 +1,39 @@
 package window
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 type Rolling struct {
 	data   []float64
+	times  []time.Time
 	size   int
 	idx    int
 	filled bool
+	count  uint64
 	mu     sync.RWMutex
 }
 
 func New(size int) *Rolling {
 	return &Rolling{
-		data: make([]float64, size),
-		size: size,
+		data:  make([]float64, size),
+		times: make([]time.Time, size),
+		size:  size,
 	}
 }
 
 func (r *Rolling) Push(v float64) {
+	r.PushAt(v, time.Time{})
+}
+
+// PushAt adds a value observed at t.
+func (r *Rolling) PushAt(v float64, t time.Time) {
 	r.mu.Lock()
 	r.data[r.idx] = v
+	r.times[r.idx] = t
 	r.idx++
+	r.count++
 	if r.idx == r.size {
 		r.idx = 0
 		r.filled = true
@@ -32,15 +45,38 @@ func (r *Rolling) Snapshot() []float64 {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if !r.filled {
-		out := make([]float64, r.idx)
-		copy(out, r.data[:r.idx])
+	return Unroll(r.data, r.idx, r.filled)
+}
+
+// SnapshotTimes returns the values and their timestamps, oldest first.
+// No estimator reads the timestamps; they are kept so a window's contents
+// can be inspected, for example to check where grid samples fell.
+func (r *Rolling) SnapshotTimes() ([]float64, []time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return Unroll(r.data, r.idx, r.filled), Unroll(r.times, r.idx, r.filled)
+}
+
+// Count is the number of values pushed since the window was created.
+func (r *Rolling) Count() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.count
+}
+
+// Unroll copies a ring buffer out oldest first, given the next write
+// index and whether the buffer has wrapped.
+func Unroll[T any](buf []T, idx int, filled bool) []T {
+	if !filled {
+		out := make([]T, idx)
+		copy(out, buf[:idx])
 		return out
 	}
 
-	out := make([]float64, r.size)
-	n := r.size - r.idx
-	copy(out, r.data[r.idx:])
-	copy(out[n:], r.data[:r.idx])
+	out := make([]T, len(buf))
+	n := len(buf) - idx
+	copy(out, buf[idx:])
+	copy(out[n:], buf[:idx])
 	return out
 }